@@ -1,6 +1,10 @@
 package cmd
 
 import (
+	"path/filepath"
+	"time"
+
+	homedir "github.com/mitchellh/go-homedir"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 	"github.com/sw33tLie/bbscope/internal/utils"
@@ -10,18 +14,23 @@ import (
 
 // bcCmd represents the bc command
 var bcCmd = &cobra.Command{
-	Use:   "bc",
-	Short: "Bugcrowd",
-	Long:  "Gathers data from Bugcrowd (https://bugcrowd.com/)",
+	Use:     "bc",
+	Aliases: []string{"bugcrowd"},
+	Short:   "Bugcrowd",
+	Long:    "Gathers data from Bugcrowd (https://bugcrowd.com/)",
+	Args:    cobra.NoArgs,
 	Run: func(cmd *cobra.Command, args []string) {
 		var err error
 		token, _ := cmd.Flags().GetString("token")
 		categories, _ := cmd.Flags().GetString("categories")
 		concurrency, _ := cmd.Flags().GetInt("concurrency")
+		noSessionCache, _ := cmd.Flags().GetBool("no-session-cache")
+		wafMaxWait, _ := cmd.Flags().GetDuration("waf-max-wait")
 
 		outputFlags, _ := rootCmd.PersistentFlags().GetString("output")
 		delimiterCharacter, _ := rootCmd.PersistentFlags().GetString("delimiter")
 		includeOOS, _ := rootCmd.PersistentFlags().GetBool("oos")
+		format := getOutputFormat()
 
 		proxy, _ := rootCmd.PersistentFlags().GetString("proxy")
 		bbpOnly, _ := rootCmd.Flags().GetBool("bbpOnly")
@@ -34,14 +43,35 @@ var bcCmd = &cobra.Command{
 			whttp.SetupProxy(proxy)
 		}
 
+		// Sessions passed with --token can't be renewed if they expire during the run
+		var login func() (string, error)
+
 		if email != "" && password != "" && token == "" {
-			token, err = bugcrowd.Login(email, password, proxy)
+			login = func() (string, error) {
+				return bugcrowd.Login(email, password, proxy)
+			}
+
+			if !noSessionCache {
+				home, homeErr := homedir.Dir()
+				if homeErr != nil {
+					utils.Log.Fatal("[bc] ", homeErr)
+				}
+				cachePath := filepath.Join(home, ".config", "bbscope", "sessions", "bugcrowd.json")
+				login = func() (string, error) {
+					return bugcrowd.LoginWithCache(email, password, proxy, cachePath)
+				}
+			}
+
+			token, err = login()
 			if err != nil {
 				utils.Log.Fatal("[bc] ", err)
 			}
 		}
 
-		_, err = bugcrowd.GetAllProgramsScope(token, bbpOnly, pvtOnly, categories, outputFlags, concurrency, delimiterCharacter, includeOOS, true, nil)
+		programs, err := bugcrowd.GetAllProgramsScope(token, bbpOnly, pvtOnly, categories, outputFlags, concurrency, delimiterCharacter, includeOOS, isRealTimeOutput(format), nil, wafMaxWait, login)
+
+		printPrograms(programs, "bugcrowd", format, includeOOS)
+		exportPrograms(programs, "bugcrowd", err == nil)
 
 		if err != nil {
 			utils.Log.Fatal("[bc] ", err)
@@ -63,4 +93,7 @@ func init() {
 	bcCmd.Flags().StringP("password", "P", "", "Login password")
 	viper.BindPFlag("bugcrowd-password", bcCmd.Flags().Lookup("password"))
 
+	bcCmd.Flags().BoolP("no-session-cache", "", false, "Always perform a full login instead of reusing the session cached in ~/.config/bbscope/sessions/bugcrowd.json")
+	bcCmd.Flags().DurationP("waf-max-wait", "", 80*time.Minute, "Maximum total time to wait for WAF bans to expire before giving up (0 to give up immediately)")
+
 }
@@ -0,0 +1,57 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+	"github.com/sw33tLie/bbscope/internal/utils"
+	"github.com/sw33tLie/bbscope/pkg/platforms/cobalt"
+	"github.com/sw33tLie/bbscope/pkg/whttp"
+)
+
+// cobaltCmd represents the cobalt command
+var cobaltCmd = &cobra.Command{
+	Use:   "cobalt",
+	Short: "Cobalt",
+	Long:  "Gathers data from Cobalt (https://cobalt.io/)",
+	Args:  cobra.NoArgs,
+	// Cobalt runs pentests, so there are no bounties, public programs or out-of-scope assets to filter
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		return rejectFlags(cmd, "bbpOnly", "pvtOnly", "oos")
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		token, _ := cmd.Flags().GetString("token")
+		orgToken, _ := cmd.Flags().GetString("org-token")
+
+		outputFlags, _ := rootCmd.PersistentFlags().GetString("output")
+		delimiterCharacter, _ := rootCmd.PersistentFlags().GetString("delimiter")
+		proxy, _ := rootCmd.PersistentFlags().GetString("proxy")
+		format := getOutputFormat()
+
+		// Cobalt assets have no target, so print what they do have unless told otherwise
+		if !rootCmd.PersistentFlags().Changed("output") {
+			outputFlags = "cdu"
+		}
+
+		if token == "" {
+			utils.Log.Fatal("Please provide your Cobalt API token (-t flag)")
+		}
+
+		if proxy != "" {
+			whttp.SetupProxy(proxy)
+		}
+
+		programs, err := cobalt.GetAllProgramsScope(token, orgToken, outputFlags, delimiterCharacter, isRealTimeOutput(format))
+
+		printPrograms(programs, "cobalt", format, false)
+		exportPrograms(programs, "cobalt", err == nil)
+
+		if err != nil {
+			utils.Log.Fatal("[cobalt] ", err)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(cobaltCmd)
+	cobaltCmd.Flags().StringP("token", "t", "", "Cobalt API token, get it here: https://app.cobalt.io/settings/api-token")
+	cobaltCmd.Flags().StringP("org-token", "", "", "Cobalt organization token (default: all organizations you belong to)")
+}
@@ -15,13 +15,16 @@ import (
 
 // h1Cmd represents the h1 command
 var h1Cmd = &cobra.Command{
-	Use:   "h1",
-	Short: "HackerOne",
-	Long:  "Gathers data from HackerOne (https://hackerone.com/)",
+	Use:     "h1",
+	Aliases: []string{"hackerone"},
+	Short:   "HackerOne",
+	Long:    "Gathers data from HackerOne (https://hackerone.com/)",
+	Args:    cobra.NoArgs,
 	Run: func(cmd *cobra.Command, args []string) {
 		token, _ := cmd.Flags().GetString("token")
 		username, _ := cmd.Flags().GetString("username")
 		categories, _ := cmd.Flags().GetString("categories")
+		minSeverity, _ := cmd.Flags().GetString("min-severity")
 		publicOnly, _ := cmd.Flags().GetBool("public-only")
 		active, _ := cmd.Flags().GetBool("active-only")
 
@@ -32,6 +35,7 @@ var h1Cmd = &cobra.Command{
 		bbpOnly, _ := rootCmd.Flags().GetBool("bbpOnly")
 		pvtOnly, _ := rootCmd.Flags().GetBool("pvtOnly")
 		concurrency, _ := cmd.Flags().GetInt("concurrency")
+		format := getOutputFormat()
 
 		if username == "" {
 			log.Fatal("Please provide your HackerOne username (-u flag)")
@@ -49,7 +53,11 @@ var h1Cmd = &cobra.Command{
 			whttp.SetupProxy(proxy)
 		}
 
-		hackerone.GetAllProgramsScope(b64.StdEncoding.EncodeToString([]byte(username+":"+token)), bbpOnly, pvtOnly, publicOnly, categories, active, concurrency, true, outputFlags, delimiterCharacter, includeOOS)
+		programs, err := hackerone.GetAllProgramsScope(b64.StdEncoding.EncodeToString([]byte(username+":"+token)), bbpOnly, pvtOnly, publicOnly, categories, minSeverity, active, concurrency, isRealTimeOutput(format), outputFlags, delimiterCharacter, includeOOS)
+		printPrograms(programs, "hackerone", format, includeOOS)
+
+		// Programs that failed are only logged, but their exported files must be kept
+		exportPrograms(programs, "hackerone", err == nil)
 	},
 }
 
@@ -60,6 +68,7 @@ func init() {
 	h1Cmd.Flags().StringP("username", "u", "", "HackerOne username")
 	h1Cmd.Flags().StringP("token", "t", "", "HackerOne API token, get it here: https://hackerone.com/settings/api_token/edit")
 	h1Cmd.Flags().StringP("categories", "c", "all", "Scope categories, comma separated (Available: all, url, cidr, mobile, android, apple, ai, other, hardware, code, executable)")
+	h1Cmd.Flags().StringP("min-severity", "", "none", "Only print in-scope assets accepting at least this severity (Available: none, low, medium, high, critical)")
 	h1Cmd.Flags().BoolP("public-only", "", false, "Only print scope for public programs")
 	h1Cmd.Flags().BoolP("active-only", "a", false, "Show only active programs")
 	h1Cmd.Flags().IntP("concurrency", "", 3, "Concurrency of HTTP requests sent for fetching data")
@@ -72,6 +81,7 @@ var hacktivityCmd = &cobra.Command{
 	Use:   "hacktivity",
 	Short: "HackerOne Activity",
 	Long:  "Displays activity data from HackerOne",
+	Args:  cobra.NoArgs,
 	Run: func(cmd *cobra.Command, args []string) {
 		proxy, _ := rootCmd.PersistentFlags().GetString("proxy")
 		pages, _ := cmd.Flags().GetInt("pages")
@@ -3,6 +3,7 @@ package cmd
 import (
 	"github.com/spf13/cobra"
 	"github.com/sw33tLie/bbscope/pkg/platforms/immunefi"
+	"github.com/sw33tLie/bbscope/pkg/scope"
 	"github.com/sw33tLie/bbscope/pkg/whttp"
 )
 
@@ -11,23 +12,36 @@ var immunefiCmd = &cobra.Command{
 	Use:   "immunefi",
 	Short: "Immunefi",
 	Long:  "Gathers data from Immunefi (https://immunefi.com/explore)",
+	Args:  cobra.NoArgs,
 	Run: func(cmd *cobra.Command, args []string) {
 		proxy, _ := rootCmd.PersistentFlags().GetString("proxy")
 		categories, _ := cmd.Flags().GetString("categories")
 		outputFlags, _ := rootCmd.PersistentFlags().GetString("output")
 		delimiterCharacter, _ := rootCmd.PersistentFlags().GetString("delimiter")
-		concurrency, _ := cmd.Flags().GetInt("concurrency")
+		includeOOS, _ := rootCmd.PersistentFlags().GetBool("oos")
+		format := getOutputFormat()
 
 		if proxy != "" {
 			whttp.SetupProxy(proxy)
 		}
 
-		immunefi.PrintAllScope(categories, outputFlags, delimiterCharacter, concurrency)
+		programs := immunefi.GetAllProgramsScope(categories, includeOOS)
+
+		if isRealTimeOutput(format) {
+			for _, pData := range programs {
+				scope.PrintProgramScope(pData, outputFlags, delimiterCharacter, includeOOS)
+			}
+		} else {
+			printPrograms(programs, "immunefi", format, includeOOS)
+		}
+
+		exportPrograms(programs, "immunefi", true)
 	},
 }
 
 func init() {
 	rootCmd.AddCommand(immunefiCmd)
-	immunefiCmd.Flags().StringP("categories", "c", "all", "Scope categories, comma separated (Available: all, web, contracts)")
+	immunefiCmd.Flags().StringP("categories", "c", "all", "Scope categories, comma separated (Available: all, web, contracts, blockchain)")
 	immunefiCmd.Flags().IntP("concurrency", "", 5, "Concurrency threshold")
+	immunefiCmd.Flags().MarkDeprecated("concurrency", "all programs are now fetched with a single request")
 }
@@ -8,9 +8,11 @@ import (
 
 // itCmd represents the it command
 var itCmd = &cobra.Command{
-	Use:   "it",
-	Short: "Intigriti",
-	Long:  "Gathers data from Intigriti (https://intigriti.com/)",
+	Use:     "it",
+	Aliases: []string{"intigriti"},
+	Short:   "Intigriti",
+	Long:    "Gathers data from Intigriti (https://intigriti.com/)",
+	Args:    cobra.NoArgs,
 	Run: func(cmd *cobra.Command, args []string) {
 		token, _ := cmd.Flags().GetString("token")
 
@@ -19,6 +21,7 @@ var itCmd = &cobra.Command{
 		outputFlags, _ := rootCmd.PersistentFlags().GetString("output")
 		delimiterCharacter, _ := rootCmd.PersistentFlags().GetString("delimiter")
 		includeOOS, _ := rootCmd.PersistentFlags().GetBool("oos")
+		format := getOutputFormat()
 
 		proxy, _ := rootCmd.PersistentFlags().GetString("proxy")
 		bbpOnly, _ := rootCmd.Flags().GetBool("bbpOnly")
@@ -28,7 +31,9 @@ var itCmd = &cobra.Command{
 			whttp.SetupProxy(proxy)
 		}
 
-		intigriti.GetAllProgramsScope(token, bbpOnly, pvtOnly, categories, outputFlags, delimiterCharacter, includeOOS, true)
+		programs := intigriti.GetAllProgramsScope(token, bbpOnly, pvtOnly, categories, outputFlags, delimiterCharacter, includeOOS, isRealTimeOutput(format))
+		printPrograms(programs, "intigriti", format, includeOOS)
+		exportPrograms(programs, "intigriti", true)
 	},
 }
 
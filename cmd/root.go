@@ -8,6 +8,9 @@ import (
 
 	"github.com/spf13/cobra"
 	"github.com/sw33tLie/bbscope/internal/utils"
+	"github.com/sw33tLie/bbscope/pkg/export"
+	"github.com/sw33tLie/bbscope/pkg/scope"
+	"github.com/sw33tLie/bbscope/pkg/whttp"
 
 	homedir "github.com/mitchellh/go-homedir"
 	"github.com/spf13/viper"
@@ -37,13 +40,17 @@ func init() {
 
 	// Global flags
 	rootCmd.PersistentFlags().StringP("proxy", "", "", "HTTP Proxy (Useful for debugging. Example: http://127.0.0.1:8080)")
-	rootCmd.PersistentFlags().StringP("output", "o", "t", "Output flags. Supported: t (target), d (target description), c (category), u (program URL). Can be combined. Example: -o tdu")
+	rootCmd.PersistentFlags().StringP("output", "o", "t", "Output flags. Supported: t (target), d (target description), c (category), u (program URL), s (max severity, HackerOne only), m (bounty range, Intigriti only), r (tier, Intigriti only). Can be combined. Example: -o tdu")
 	rootCmd.PersistentFlags().StringP("delimiter", "d", " ", "Delimiter character used when printing multiple data using the output flag")
+	rootCmd.PersistentFlags().StringP("format", "", "txt", "Output format. Supported: txt, json, jsonl (one object per line), csv, burp (Burp Suite scope file), zap (OWASP ZAP context file). The output and delimiter flags only apply to txt")
+	rootCmd.PersistentFlags().StringP("deduplicate", "", "", "Remove in-scope targets already listed by another program. Requires a strategy: normalized, wildcard (also drops subdomains covered by an in-scope wildcard). Programs are printed once all of them are fetched")
+	rootCmd.PersistentFlags().StringP("export-dir", "", "", "Also write the in-scope targets of each program to <platform>_<handle>.txt in this directory, along with an index.json file")
 	rootCmd.PersistentFlags().BoolP("bbpOnly", "b", false, "Only fetch programs offering monetary rewards (by default private programs are included)")
 	rootCmd.PersistentFlags().BoolP("pvtOnly", "p", false, "Only fetch data from private programs")
 	rootCmd.PersistentFlags().StringP("loglevel", "l", "info", "Set log level. Available: debug, info, warn, error, fatal")
 	rootCmd.PersistentFlags().BoolP("oos", "", false, "Also print out of scope items with [OOS] - Intigriti only for now")
-
+	rootCmd.PersistentFlags().StringP("rate-limit", "", "", "Per host rate limits, comma separated. Subdomains share their parent's limit, an optional burst follows ':'. Example: bugcrowd.com=1/s,hackerone.com=5/s:10 (default bugcrowd.com=1/s)")
+	viper.BindPFlag("rate-limit", rootCmd.PersistentFlags().Lookup("rate-limit"))
 }
 
 // initConfig reads in config file and ENV variables if set.
@@ -75,6 +82,110 @@ func initConfig() {
 	levelString, _ := rootCmd.PersistentFlags().GetString("loglevel")
 	utils.SetLogLevel(levelString)
 
+	if rateLimits := viper.GetString("rate-limit"); rateLimits != "" {
+		if err := whttp.SetRateLimits(rateLimits); err != nil {
+			utils.Log.Fatal(err)
+		}
+	}
+
 	// Initialize rand for any subcommand
 	rand.Seed(time.Now().Unix())
 }
+
+// getOutputFormat returns the validated --format value
+func getOutputFormat() string {
+	format, _ := rootCmd.PersistentFlags().GetString("format")
+
+	switch format {
+	case "txt":
+	case "json", "jsonl", "csv", "burp", "zap":
+		if rootCmd.PersistentFlags().Changed("output") || rootCmd.PersistentFlags().Changed("delimiter") {
+			utils.Log.Warn("The output and delimiter flags are ignored with --format ", format)
+		}
+	default:
+		utils.Log.Fatal("Invalid output format: ", format)
+	}
+
+	return format
+}
+
+// getDedupStrategy returns the validated --deduplicate value, empty if disabled
+func getDedupStrategy() scope.DedupStrategy {
+	strategy, _ := rootCmd.PersistentFlags().GetString("deduplicate")
+
+	switch scope.DedupStrategy(strategy) {
+	case "", scope.DedupByNormalized, scope.DedupByWildcard:
+	default:
+		utils.Log.Fatal("Invalid deduplication strategy: ", strategy)
+	}
+
+	return scope.DedupStrategy(strategy)
+}
+
+// rejectFlags returns an error if any of the given flags was set, for platforms that can't honor them
+func rejectFlags(cmd *cobra.Command, names ...string) error {
+	for _, name := range names {
+		if flag := cmd.Flags().Lookup(name); flag != nil && flag.Changed {
+			return fmt.Errorf("the --%s flag is not supported by %s", name, cmd.Name())
+		}
+	}
+
+	return nil
+}
+
+// isRealTimeOutput reports whether programs can be printed while being fetched
+func isRealTimeOutput(format string) bool {
+	return format == "txt" && getDedupStrategy() == ""
+}
+
+// printPrograms prints programs once all of them are fetched.
+// Nothing is done if they were already printed in real time
+func printPrograms(programs []scope.ProgramData, platform string, format string, includeOOS bool) {
+	if strategy := getDedupStrategy(); strategy != "" {
+		programs = scope.Dedup(programs, strategy)
+	}
+
+	switch format {
+	case "txt":
+		if !isRealTimeOutput(format) {
+			outputFlags, _ := rootCmd.PersistentFlags().GetString("output")
+			delimiter, _ := rootCmd.PersistentFlags().GetString("delimiter")
+			for _, pData := range programs {
+				scope.PrintProgramScope(pData, outputFlags, delimiter, includeOOS)
+			}
+		}
+	case "json":
+		if err := scope.WriteJSON(os.Stdout, programs, platform, includeOOS); err != nil {
+			utils.Log.Fatal("Failed to write JSON: ", err)
+		}
+	case "jsonl":
+		for _, pData := range programs {
+			scope.PrintProgramScopeJSON(pData, platform, includeOOS)
+		}
+	case "csv":
+		if err := scope.WriteCSV(os.Stdout, programs, platform, includeOOS); err != nil {
+			utils.Log.Fatal("Failed to write CSV: ", err)
+		}
+	case "burp":
+		if err := export.WriteBurp(os.Stdout, programs, includeOOS); err != nil {
+			utils.Log.Fatal("Failed to write Burp scope: ", err)
+		}
+	case "zap":
+		if err := export.WriteZAP(os.Stdout, programs, "bbscope-"+platform, includeOOS); err != nil {
+			utils.Log.Fatal("Failed to write ZAP context: ", err)
+		}
+	}
+}
+
+// exportPrograms writes programs to the --export-dir directory, if set.
+// complete must be false when some programs could not be fetched, so that their files are kept
+func exportPrograms(programs []scope.ProgramData, platform string, complete bool) {
+	exportDir, _ := rootCmd.PersistentFlags().GetString("export-dir")
+	if exportDir == "" {
+		return
+	}
+
+	if err := export.WriteProgramFiles(exportDir, programs, platform, complete); err != nil {
+		utils.Log.Fatal("Failed to export programs: ", err)
+	}
+}
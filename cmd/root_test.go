@@ -0,0 +1,91 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/sw33tLie/bbscope/pkg/scope"
+)
+
+func TestDeduplicateFlagTakesValue(t *testing.T) {
+	tests := []struct {
+		args []string
+		want scope.DedupStrategy
+	}{
+		{[]string{"--deduplicate", "wildcard"}, scope.DedupByWildcard},
+		{[]string{"--deduplicate=wildcard"}, scope.DedupByWildcard},
+		{[]string{"--deduplicate", "normalized"}, scope.DedupByNormalized},
+	}
+
+	t.Cleanup(func() {
+		rootCmd.PersistentFlags().Set("deduplicate", "")
+	})
+
+	for _, test := range tests {
+		if err := h1Cmd.ParseFlags(test.args); err != nil {
+			t.Fatalf("%v: %v", test.args, err)
+		}
+
+		if got := getDedupStrategy(); got != test.want {
+			t.Errorf("%v: got strategy %q, want %q", test.args, got, test.want)
+		}
+
+		if err := h1Cmd.ValidateArgs(h1Cmd.Flags().Args()); err != nil {
+			t.Errorf("%v: unexpected positional args: %v", test.args, err)
+		}
+	}
+
+	if err := h1Cmd.ParseFlags([]string{"--deduplicate"}); err == nil {
+		t.Error("--deduplicate without a strategy should fail")
+	}
+}
+
+// resetFlags restores the default value of persistent flags set by a test
+func resetFlags(names ...string) {
+	for _, name := range names {
+		flag := rootCmd.PersistentFlags().Lookup(name)
+		flag.Value.Set(flag.DefValue)
+		flag.Changed = false
+	}
+}
+
+func TestCobaltRejectsUnsupportedFlags(t *testing.T) {
+	unsupported := []string{"bbpOnly", "pvtOnly", "oos"}
+	t.Cleanup(func() {
+		resetFlags(unsupported...)
+	})
+
+	for _, args := range [][]string{{"-b"}, {"-p"}, {"--oos"}} {
+		resetFlags(unsupported...)
+
+		if err := cobaltCmd.ParseFlags(args); err != nil {
+			t.Fatalf("%v: %v", args, err)
+		}
+
+		if err := cobaltCmd.PreRunE(cobaltCmd, nil); err == nil {
+			t.Errorf("%v should be rejected", args)
+		}
+	}
+
+	resetFlags(unsupported...)
+	if err := cobaltCmd.PreRunE(cobaltCmd, nil); err != nil {
+		t.Errorf("no unsupported flag set, got %v", err)
+	}
+
+	// Categories aren't a Cobalt flag at all
+	if err := cobaltCmd.ParseFlags([]string{"-c", "url"}); err == nil {
+		t.Error("-c should be rejected")
+	}
+}
+
+func TestPlatformCommandsRejectArgs(t *testing.T) {
+	for _, name := range []string{"h1", "bc", "it", "ywh", "immunefi", "cobalt"} {
+		platformCmd, _, err := rootCmd.Find([]string{name})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if err := platformCmd.ValidateArgs([]string{"wildcard"}); err == nil {
+			t.Errorf("%s accepts positional args", name)
+		}
+	}
+}
@@ -2,15 +2,19 @@ package cmd
 
 import (
 	"github.com/spf13/cobra"
+	"github.com/sw33tLie/bbscope/internal/utils"
 	"github.com/sw33tLie/bbscope/pkg/platforms/yeswehack"
+	"github.com/sw33tLie/bbscope/pkg/scope"
 	"github.com/sw33tLie/bbscope/pkg/whttp"
 )
 
 // ywhCmd represents the ywh command
 var ywhCmd = &cobra.Command{
-	Use:   "ywh",
-	Short: "YesWeHack",
-	Long:  "Gathers data from YesWeHack (https://yeswehack.com/)",
+	Use:     "ywh",
+	Aliases: []string{"yeswehack"},
+	Short:   "YesWeHack",
+	Long:    "Gathers data from YesWeHack (https://yeswehack.com/)",
+	Args:    cobra.NoArgs,
 	Run: func(cmd *cobra.Command, args []string) {
 		token, _ := cmd.Flags().GetString("token")
 
@@ -21,12 +25,27 @@ var ywhCmd = &cobra.Command{
 		proxy, _ := rootCmd.PersistentFlags().GetString("proxy")
 		bbpOnly, _ := rootCmd.Flags().GetBool("bbpOnly")
 		pvtOnly, _ := rootCmd.Flags().GetBool("pvtOnly")
+		format := getOutputFormat()
 
 		if proxy != "" {
 			whttp.SetupProxy(proxy)
 		}
 
-		yeswehack.PrintAllScope(token, bbpOnly, pvtOnly, categories, outputFlags, delimiterCharacter)
+		programs, err := yeswehack.GetAllProgramsScope(token, bbpOnly, pvtOnly, categories)
+
+		if isRealTimeOutput(format) {
+			for _, pData := range programs {
+				scope.PrintProgramScope(pData, outputFlags, delimiterCharacter, false)
+			}
+		} else {
+			printPrograms(programs, "yeswehack", format, false)
+		}
+
+		exportPrograms(programs, "yeswehack", err == nil)
+
+		if err != nil {
+			utils.Log.Fatal("[ywh] ", err)
+		}
 	},
 }
 
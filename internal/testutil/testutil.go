@@ -0,0 +1,125 @@
+// Package testutil routes the default whttp client to test servers, so that platform
+// packages can be tested against fixtures instead of the real APIs
+package testutil
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/sw33tLie/bbscope/pkg/scope"
+	"github.com/sw33tLie/bbscope/pkg/whttp"
+)
+
+// RewriteTransport sends every request to Target, whatever its original host, which is kept in the Host header.
+// Requests are cloned, so that cookie jars and responses still see the original URL
+type RewriteTransport struct {
+	Target *url.URL
+}
+
+func (t RewriteTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	rewritten := req.Clone(req.Context())
+	if rewritten.Host == "" {
+		rewritten.Host = rewritten.URL.Host
+	}
+	rewritten.URL.Scheme = t.Target.Scheme
+	rewritten.URL.Host = t.Target.Host
+
+	resp, err := http.DefaultTransport.RoundTrip(rewritten)
+	if err != nil {
+		return nil, err
+	}
+
+	resp.Request = req
+	return resp, nil
+}
+
+// UseTestServer routes the default whttp client to handler for the duration of the test
+func UseTestServer(t testing.TB, handler http.Handler) *httptest.Server {
+	server := httptest.NewServer(handler)
+	serverURL, _ := url.Parse(server.URL)
+
+	client := whttp.GetDefaultClient()
+	originalTransport := client.HTTPClient.Transport
+	client.HTTPClient.Transport = RewriteTransport{Target: serverURL}
+
+	t.Cleanup(func() {
+		client.HTTPClient.Transport = originalTransport
+		server.Close()
+	})
+
+	return server
+}
+
+// ReadFixture returns the content of a file of the testdata directory
+func ReadFixture(t testing.TB, fixture string) []byte {
+	body, err := ioutil.ReadFile(filepath.Join("testdata", fixture))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return body
+}
+
+// ServeFixture answers every request with the given status code and testdata file
+func ServeFixture(t testing.TB, statusCode int, fixture string) {
+	body := ReadFixture(t, fixture)
+
+	UseTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(statusCode)
+		w.Write(body)
+	}))
+}
+
+// Body answers every request with the same JSON body, which can be swapped between requests, e.g. by fuzz targets
+type Body struct {
+	mu      sync.Mutex
+	content []byte
+}
+
+func (b *Body) Set(content []byte) {
+	b.mu.Lock()
+	b.content = content
+	b.mu.Unlock()
+}
+
+func (b *Body) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	b.mu.Lock()
+	content := b.content
+	b.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(content)
+}
+
+// FailOnParseError fails the test if err is a panic recovered by scope.RecoverParseError
+func FailOnParseError(t *testing.T, err error) {
+	var parseErr *scope.ParseError
+	if errors.As(err, &parseErr) {
+		t.Errorf("parser panicked: %v", parseErr)
+	}
+}
+
+// CheckCategoriesReachable fails for each known platform category that none of the given -c values selects
+func CheckCategoriesReachable[T comparable](t *testing.T, known []T, names []string, getCategories func(string) []T) {
+	t.Helper()
+
+	reachable := make(map[T]bool)
+	for _, name := range names {
+		for _, category := range getCategories(name) {
+			reachable[category] = true
+		}
+	}
+
+	for _, category := range known {
+		if !reachable[category] {
+			t.Errorf("category %v is not reachable from %v", category, names)
+		}
+	}
+}
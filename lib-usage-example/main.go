@@ -28,7 +28,10 @@ func main() {
 	}
 
 	// All platforms are supported, syntax is similar
-	scope := hackerone.GetAllProgramsScope(b64.StdEncoding.EncodeToString([]byte(*userFlag+":"+*tokenFlag)), true, true, false, "all", true, 2, false, "", "", true)
+	scope, err := hackerone.GetAllProgramsScope(b64.StdEncoding.EncodeToString([]byte(*userFlag+":"+*tokenFlag)), true, true, false, "all", "none", true, 2, false, "", "", true)
+	if err != nil {
+		fmt.Println("Some programs could not be fetched:", err)
+	}
 
 	for _, s := range scope {
 		for _, elem := range s.InScope {
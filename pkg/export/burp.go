@@ -0,0 +1,74 @@
+package export
+
+import (
+	"encoding/json"
+	"io"
+
+	"github.com/sw33tLie/bbscope/pkg/scope"
+)
+
+// BurpRule is a rule of Burp Suite's advanced target scope
+type BurpRule struct {
+	Enabled  bool   `json:"enabled"`
+	File     string `json:"file,omitempty"`
+	Host     string `json:"host"`
+	Port     string `json:"port,omitempty"`
+	Protocol string `json:"protocol"`
+}
+
+// BurpScope is the target scope file imported by Burp Suite (Target > Scope settings > Load)
+type BurpScope struct {
+	Target struct {
+		Scope struct {
+			AdvancedMode bool       `json:"advanced_mode"`
+			Exclude      []BurpRule `json:"exclude"`
+			Include      []BurpRule `json:"include"`
+		} `json:"scope"`
+	} `json:"target"`
+}
+
+func getBurpRules(targets []target) []BurpRule {
+	rules := []BurpRule{}
+	seen := make(map[BurpRule]bool)
+
+	for _, t := range targets {
+		rule := BurpRule{
+			Enabled:  true,
+			Host:     HostRegex(t.host),
+			Protocol: "any",
+		}
+
+		if t.protocol != "" {
+			rule.Protocol = t.protocol
+		}
+
+		if t.port != "" {
+			rule.Port = "^" + t.port + "$"
+		}
+
+		if t.path != "" {
+			rule.File = "^" + wildcardToRegex(t.path) + ".*"
+		}
+
+		if !seen[rule] {
+			seen[rule] = true
+			rules = append(rules, rule)
+		}
+	}
+
+	return rules
+}
+
+// WriteBurp writes a Burp Suite scope file. Out-of-scope elements become exclude rules
+func WriteBurp(w io.Writer, programs []scope.ProgramData, includeOOS bool) error {
+	inScope, outOfScope := getTargets(programs, includeOOS)
+
+	var burpScope BurpScope
+	burpScope.Target.Scope.AdvancedMode = true
+	burpScope.Target.Scope.Include = getBurpRules(inScope)
+	burpScope.Target.Scope.Exclude = getBurpRules(outOfScope)
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(burpScope)
+}
@@ -0,0 +1,186 @@
+package export
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/sw33tLie/bbscope/pkg/scope"
+)
+
+const INDEX_FILENAME = "index.json"
+
+// Characters allowed in file names, anything else is replaced with '_'
+var unsafeFilenameChars = regexp.MustCompile(`[^a-zA-Z0-9._-]+`)
+
+// IndexEntry describes the program a file of the export directory belongs to
+type IndexEntry struct {
+	ProgramURL string `json:"program_url"`
+	Platform   string `json:"platform"`
+	IsBBP      bool   `json:"is_bbp"`
+}
+
+// Path segments of program URLs that aren't handles, e.g. "detail" in Intigriti's /researcher/programs/<company>/<handle>/detail
+var genericPathSegments = map[string]bool{
+	"researcher":  true,
+	"programs":    true,
+	"engagements": true,
+	"bug-bounty":  true,
+	"assets":      true,
+	"detail":      true,
+}
+
+// programFilename returns "<platform>_<handle>.txt", the handle being the last specific segment of the program URL's path
+func programFilename(platform string, programURL string) string {
+	handle := programURL
+	if parsedURL, err := url.Parse(programURL); err == nil {
+		segments := strings.Split(strings.Trim(parsedURL.Path, "/"), "/")
+		for i := len(segments) - 1; i >= 0; i-- {
+			if segments[i] != "" && !genericPathSegments[strings.ToLower(segments[i])] {
+				handle = segments[i]
+				break
+			}
+		}
+	}
+
+	handle = strings.Trim(unsafeFilenameChars.ReplaceAllString(handle, "_"), "_.")
+	return unsafeFilenameChars.ReplaceAllString(platform, "_") + "_" + handle + ".txt"
+}
+
+// programFilenames maps each program URL to its file name.
+// Distinct URLs whose names collide once sanitized all get a short hash suffix, so that no program
+// is credited with another's targets, whatever order the programs were fetched in
+func programFilenames(platform string, programs []scope.ProgramData) map[string]string {
+	urlsByFilename := make(map[string]map[string]bool)
+	for _, pData := range programs {
+		filename := programFilename(platform, pData.Url)
+		if urlsByFilename[filename] == nil {
+			urlsByFilename[filename] = make(map[string]bool)
+		}
+		urlsByFilename[filename][pData.Url] = true
+	}
+
+	filenames := make(map[string]string)
+	for filename, programURLs := range urlsByFilename {
+		for programURL := range programURLs {
+			if len(programURLs) == 1 {
+				filenames[programURL] = filename
+				continue
+			}
+
+			hash := sha256.Sum256([]byte(programURL))
+			filenames[programURL] = strings.TrimSuffix(filename, ".txt") + "_" + hex.EncodeToString(hash[:4]) + ".txt"
+		}
+	}
+
+	return filenames
+}
+
+// writeFileIfChanged only writes content if it differs from the file's current content,
+// so that file watchers aren't triggered for nothing
+func writeFileIfChanged(path string, content []byte) error {
+	if current, err := ioutil.ReadFile(path); err == nil && sha256.Sum256(current) == sha256.Sum256(content) {
+		return nil
+	}
+
+	return ioutil.WriteFile(path, content, 0644)
+}
+
+func readIndex(dir string) (map[string]IndexEntry, error) {
+	index := make(map[string]IndexEntry)
+
+	indexBytes, err := ioutil.ReadFile(filepath.Join(dir, INDEX_FILENAME))
+	if os.IsNotExist(err) {
+		return index, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(indexBytes, &index); err != nil {
+		return nil, err
+	}
+
+	return index, nil
+}
+
+// WriteProgramFiles writes the in-scope URLs, domains and IPs of each program to <platform>_<handle>.txt in dir,
+// and maps file names to programs in dir/index.json.
+// If removeStale is set, files of this platform's programs that are no longer returned are removed.
+// It should only be set when programs holds the complete list of programs
+func WriteProgramFiles(dir string, programs []scope.ProgramData, platform string, removeStale bool) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	index, err := readIndex(dir)
+	if err != nil {
+		return err
+	}
+
+	contents := make(map[string]*bytes.Buffer)
+	seenTargets := make(map[string]map[string]bool)
+	newIndex := make(map[string]IndexEntry)
+	filenames := programFilenames(platform, programs)
+
+	for _, pData := range programs {
+		filename := filenames[pData.Url]
+
+		for _, scopeElement := range pData.InScope {
+			// Only write targets that tools can use, e.g. not app store links or free text
+			if _, ok := usableTarget(scopeElement); !ok {
+				continue
+			}
+
+			// Programs sharing the same URL share a file, e.g. Cobalt assets of the same organization
+			if contents[filename] == nil {
+				contents[filename] = new(bytes.Buffer)
+				seenTargets[filename] = make(map[string]bool)
+				newIndex[filename] = IndexEntry{ProgramURL: pData.Url, Platform: platform, IsBBP: pData.IsBBP}
+			}
+
+			if !seenTargets[filename][scopeElement.Target] {
+				seenTargets[filename][scopeElement.Target] = true
+				contents[filename].WriteString(scopeElement.Target + "\n")
+			}
+		}
+	}
+
+	for filename, content := range contents {
+		if err := writeFileIfChanged(filepath.Join(dir, filename), content.Bytes()); err != nil {
+			return err
+		}
+	}
+
+	for filename, entry := range index {
+		// Never touch files we didn't create
+		if !removeStale || entry.Platform != platform || filename != filepath.Base(filename) || !strings.HasPrefix(filename, platform+"_") {
+			continue
+		}
+
+		if _, ok := newIndex[filename]; !ok {
+			if err := os.Remove(filepath.Join(dir, filename)); err != nil && !os.IsNotExist(err) {
+				return err
+			}
+		}
+		delete(index, filename)
+	}
+
+	for filename, entry := range newIndex {
+		index[filename] = entry
+	}
+
+	indexBytes, err := json.MarshalIndent(index, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return writeFileIfChanged(filepath.Join(dir, INDEX_FILENAME), append(indexBytes, '\n'))
+}
@@ -0,0 +1,120 @@
+package export
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/sw33tLie/bbscope/pkg/scope"
+)
+
+func TestProgramFilename(t *testing.T) {
+	tests := []struct {
+		platform   string
+		programURL string
+		want       string
+	}{
+		{"hackerone", "https://hackerone.com/acme", "hackerone_acme.txt"},
+		{"bugcrowd", "https://bugcrowd.com/acme", "bugcrowd_acme.txt"},
+		{"bugcrowd", "https://bugcrowd.com/engagements/acme", "bugcrowd_acme.txt"},
+		{"intigriti", "https://app.intigriti.com/researcher/programs/acmecorp/acme/detail", "intigriti_acme.txt"},
+		{"yeswehack", "https://api.yeswehack.com/programs/acme-bug-bounty", "yeswehack_acme-bug-bounty.txt"},
+		{"immunefi", "https://immunefi.com/bug-bounty/acme/", "immunefi_acme.txt"},
+		{"cobalt", "https://app.cobalt.io/acme/assets/as_web", "cobalt_as_web.txt"},
+		{"cobalt", "https://app.cobalt.io", "cobalt_https_app.cobalt.io.txt"},
+		{"hackerone", "https://hackerone.com/../etc", "hackerone_etc.txt"},
+	}
+
+	for _, test := range tests {
+		if got := programFilename(test.platform, test.programURL); got != test.want {
+			t.Errorf("programFilename(%q, %q) = %q, want %q", test.platform, test.programURL, got, test.want)
+		}
+	}
+}
+
+func TestWriteProgramFilesSkipsUnusableTargets(t *testing.T) {
+	dir := t.TempDir()
+	programs := []scope.ProgramData{
+		{Url: "https://bugcrowd.com/engagements/acme", InScope: []scope.ScopeElement{
+			{Target: "acme.com"},
+			{Target: "https://api.acme.com/v1"},
+			{Target: "192.0.2.1"},
+			{Target: "10.0.0.0/8"},
+			{Target: "Acme iOS app"},
+			{Target: scope.TWO_FA_REQUIRED},
+			{Target: scope.NO_IN_SCOPE_TABLE},
+			{Target: ""},
+		}},
+		{Url: "https://bugcrowd.com/engagements/empty", InScope: []scope.ScopeElement{{Target: scope.TWO_FA_REQUIRED}, {Target: "Hardware"}}},
+	}
+
+	if err := WriteProgramFiles(dir, programs, "bugcrowd", true); err != nil {
+		t.Fatal(err)
+	}
+
+	content, err := ioutil.ReadFile(filepath.Join(dir, "bugcrowd_acme.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := "acme.com\nhttps://api.acme.com/v1\n192.0.2.1\n"
+	if string(content) != want {
+		t.Errorf("got content %q, want %q", content, want)
+	}
+
+	if _, err := ioutil.ReadFile(filepath.Join(dir, "bugcrowd_empty.txt")); err == nil {
+		t.Error("a program without usable targets should not get a file")
+	}
+}
+
+func TestWriteProgramFilesCollidingNames(t *testing.T) {
+	dir := t.TempDir()
+	programs := []scope.ProgramData{
+		// Both sanitize to hackerone_foo_bar.txt
+		{Url: "https://hackerone.com/foo%20bar", InScope: []scope.ScopeElement{{Target: "a.com"}}},
+		{Url: "https://hackerone.com/foo bar", InScope: []scope.ScopeElement{{Target: "b.com"}}},
+		{Url: "https://hackerone.com/other", InScope: []scope.ScopeElement{{Target: "c.com"}}},
+	}
+
+	if err := WriteProgramFiles(dir, programs, "hackerone", true); err != nil {
+		t.Fatal(err)
+	}
+
+	indexBytes, err := ioutil.ReadFile(filepath.Join(dir, INDEX_FILENAME))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var index map[string]IndexEntry
+	if err := json.Unmarshal(indexBytes, &index); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(index) != 3 {
+		t.Fatalf("got %d index entries, want 3: %v", len(index), index)
+	}
+
+	urls := []string{}
+	for filename, entry := range index {
+		urls = append(urls, entry.ProgramURL)
+
+		content, err := ioutil.ReadFile(filepath.Join(dir, filename))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(content) != len("a.com\n") {
+			t.Errorf("%s holds the targets of more than one program: %q", filename, content)
+		}
+	}
+
+	sort.Strings(urls)
+	if urls[0] != "https://hackerone.com/foo bar" || urls[1] != "https://hackerone.com/foo%20bar" {
+		t.Errorf("colliding programs missing from index: %v", urls)
+	}
+
+	if _, ok := index["hackerone_other.txt"]; !ok {
+		t.Error("non colliding program should keep its plain file name")
+	}
+}
@@ -0,0 +1,162 @@
+package export
+
+import (
+	"net"
+	"regexp"
+	"strings"
+
+	"github.com/sw33tLie/bbscope/pkg/scope"
+)
+
+// Hosts made of labels that may contain wildcards, e.g. "*.example.com" or "api-*.example.com"
+var hostRegex = regexp.MustCompile(`^[a-z0-9*_]([a-z0-9*_-]*[a-z0-9*_])?(\.[a-z0-9*_]([a-z0-9*_-]*[a-z0-9*_])?)+$`)
+
+// target is a scope element that can be used as a proxy scope rule
+type target struct {
+	protocol string // http, https or empty for any
+	host     string
+	port     string
+	path     string
+}
+
+// parseTarget extracts host, port and path from a domain, wildcard, IP or URL.
+// It returns false for anything else, such as app store links or free text
+func parseTarget(rawTarget string) (t target, ok bool) {
+	rawTarget = strings.TrimSpace(rawTarget)
+	if rawTarget == "" || strings.ContainsAny(rawTarget, " \t\n") {
+		return t, false
+	}
+
+	if i := strings.Index(rawTarget, "://"); i != -1 {
+		t.protocol = strings.ToLower(rawTarget[:i])
+		if t.protocol != "http" && t.protocol != "https" {
+			return t, false
+		}
+		rawTarget = rawTarget[i+3:]
+	}
+
+	// IP ranges can't be expressed as host rules
+	if _, _, err := net.ParseCIDR(rawTarget); err == nil {
+		return t, false
+	}
+
+	if i := strings.IndexAny(rawTarget, "/?#"); i != -1 {
+		t.path = rawTarget[i:]
+		rawTarget = rawTarget[:i]
+
+		// Rules already match any path suffix
+		t.path = strings.TrimRight(t.path, "*")
+		if t.path == "/" {
+			t.path = ""
+		}
+	}
+
+	if host, port, err := net.SplitHostPort(rawTarget); err == nil {
+		rawTarget, t.port = host, port
+	}
+
+	t.host = strings.TrimSuffix(strings.ToLower(rawTarget), ".")
+
+	// IPv6 hosts are stored without brackets, e.g. "[2001:db8::1]" becomes "2001:db8::1"
+	if ip := net.ParseIP(strings.Trim(t.host, "[]")); ip != nil {
+		t.host = strings.Trim(t.host, "[]")
+		return t, true
+	}
+
+	return t, hostRegex.MatchString(t.host)
+}
+
+// wildcardToRegex quotes s, turning each '*' into '.*'
+func wildcardToRegex(s string) string {
+	parts := strings.Split(s, "*")
+	for i, part := range parts {
+		parts[i] = regexp.QuoteMeta(part)
+	}
+	return strings.Join(parts, ".*")
+}
+
+// urlHostRegex quotes a host for use in a whole-URL regex.
+// Each '*' only matches host characters, so that "*.example.com" can't match "evil.com/.example.com"
+func urlHostRegex(host string) string {
+	if strings.Contains(host, ":") {
+		return regexp.QuoteMeta("[" + host + "]")
+	}
+
+	parts := strings.Split(host, "*")
+	for i, part := range parts {
+		parts[i] = regexp.QuoteMeta(part)
+	}
+	return strings.Join(parts, "[^/?#:@]*")
+}
+
+// HostRegex converts a host, possibly containing wildcards, to an anchored regex.
+// For example "*.example.com" becomes "^.*\.example\.com$"
+func HostRegex(host string) string {
+	return "^" + wildcardToRegex(host) + "$"
+}
+
+// Lowercased categories of assets that aren't hosts even when they look like one, e.g. the bundle ID com.acme.app.
+// Platforms are mixed, as their names don't clash
+var nonHostCategories = map[string]bool{
+	// HackerOne
+	"google_play_app_id":       true,
+	"other_apk":                true,
+	"apple_store_app_id":       true,
+	"other_ipa":                true,
+	"testflight":               true,
+	"windows_app_store_app_id": true,
+	"downloadable_executables": true,
+	"source_code":              true,
+	"hardware":                 true,
+	"ai_model":                 true,
+	"other":                    true, // Also used by Bugcrowd, Intigriti and YesWeHack
+
+	// Bugcrowd and Intigriti
+	"android": true,
+	"ios":     true,
+	"device":  true,
+
+	// YesWeHack
+	"mobile-application":         true,
+	"mobile-application-android": true,
+	"mobile-application-ios":     true,
+	"application":                true,
+
+	// Immunefi and HackerOne
+	"smart_contract": true,
+	"blockchain_dlt": true,
+
+	// Cobalt
+	"mobile": true,
+}
+
+// usableTarget parses a scope element, returning false for placeholders, app and device categories,
+// and anything else that isn't a URL, domain or IP
+func usableTarget(scopeElement scope.ScopeElement) (target, bool) {
+	if scope.IsPlaceholder(scopeElement.Target) || nonHostCategories[strings.ToLower(scopeElement.Category)] {
+		return target{}, false
+	}
+
+	return parseTarget(scopeElement.Target)
+}
+
+// getTargets returns the usable in-scope and, if includeOOS is set, out-of-scope targets of all programs
+func getTargets(programs []scope.ProgramData, includeOOS bool) (inScope []target, outOfScope []target) {
+	collect := func(elements []scope.ScopeElement) (targets []target) {
+		for _, scopeElement := range elements {
+			if t, ok := usableTarget(scopeElement); ok {
+				targets = append(targets, t)
+			}
+		}
+		return targets
+	}
+
+	for _, pData := range programs {
+		inScope = append(inScope, collect(pData.InScope)...)
+		if includeOOS {
+			outOfScope = append(outOfScope, collect(pData.OutOfScope)...)
+		}
+	}
+
+	return inScope, outOfScope
+}
@@ -0,0 +1,209 @@
+package export
+
+import (
+	"bytes"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/sw33tLie/bbscope/pkg/scope"
+)
+
+func TestParseTarget(t *testing.T) {
+	tests := []struct {
+		rawTarget string
+		ok        bool
+		want      target
+	}{
+		{rawTarget: "example.com", ok: true, want: target{host: "example.com"}},
+		{rawTarget: "*.Example.com.", ok: true, want: target{host: "*.example.com"}},
+		{rawTarget: "example.com:8443", ok: true, want: target{host: "example.com", port: "8443"}},
+		{rawTarget: "https://example.com/api/*", ok: true, want: target{protocol: "https", host: "example.com", path: "/api/"}},
+		{rawTarget: "http://example.com/", ok: true, want: target{protocol: "http", host: "example.com"}},
+		{rawTarget: "192.0.2.1", ok: true, want: target{host: "192.0.2.1"}},
+		{rawTarget: "2001:db8::1", ok: true, want: target{host: "2001:db8::1"}},
+		{rawTarget: "[2001:db8::1]", ok: true, want: target{host: "2001:db8::1"}},
+		{rawTarget: "[2001:db8::1]:8443", ok: true, want: target{host: "2001:db8::1", port: "8443"}},
+		{rawTarget: "10.0.0.0/8", ok: false},
+		{rawTarget: "2001:db8::/32", ok: false},
+		{rawTarget: "ftp://example.com", ok: false},
+		{rawTarget: "Example mobile app", ok: false},
+		{rawTarget: "", ok: false},
+	}
+
+	for _, test := range tests {
+		got, ok := parseTarget(test.rawTarget)
+		if ok != test.ok {
+			t.Errorf("parseTarget(%q) ok = %v, want %v", test.rawTarget, ok, test.ok)
+			continue
+		}
+
+		if ok && got != test.want {
+			t.Errorf("parseTarget(%q) = %+v, want %+v", test.rawTarget, got, test.want)
+		}
+	}
+}
+
+func TestURLRegex(t *testing.T) {
+	tests := []struct {
+		rawTarget string
+		url       string
+		match     bool
+	}{
+		{"*.example.com", "https://api.example.com", true},
+		{"*.example.com", "https://a.b.example.com/path?q=1", true},
+		{"*.example.com", "http://api.example.com:8080/", true},
+		{"*.example.com", "https://example.com/", false},
+		{"*.example.com", "https://evil.com/.example.com", false},
+		{"*.example.com", "https://evil.com/?q=.example.com", false},
+		{"*.example.com", "https://evil.com#.example.com", false},
+		{"*.example.com", "https://api.example.com.evil.com/", false},
+		{"*.example.com", "https://api.example.com@evil.com/", false},
+		{"api-*.example.com", "https://api-v2.example.com/", true},
+		{"api-*.example.com", "https://api-evil.com/.example.com", false},
+		{"example.com", "https://example.com/", true},
+		{"example.com", "https://www.example.com/", false},
+		{"example.com", "https://example.com.evil.com/", false},
+		{"example.com:8443", "https://example.com:8443/", true},
+		{"example.com:8443", "https://example.com/", false},
+		{"example.com:8443", "https://example.com:9443/", false},
+		{"https://example.com/api/*", "https://example.com/api/v1", true},
+		{"https://example.com/api/*", "http://example.com/api/v1", false},
+		{"https://example.com/api/*", "https://example.com/other", false},
+		{"192.0.2.1", "http://192.0.2.1/", true},
+		{"192.0.2.1", "http://192.0.2.10/", false},
+		{"[2001:db8::1]:8443", "https://[2001:db8::1]:8443/", true},
+		{"[2001:db8::1]:8443", "https://[2001:db8::1]/", false},
+		{"2001:db8::1", "http://[2001:db8::1]/", true},
+	}
+
+	for _, test := range tests {
+		parsedTarget, ok := parseTarget(test.rawTarget)
+		if !ok {
+			t.Fatalf("parseTarget(%q) failed", test.rawTarget)
+		}
+
+		regex := urlRegex(parsedTarget)
+		if match := regexp.MustCompile(regex).MatchString(test.url); match != test.match {
+			t.Errorf("%s (%q) matches %q = %v, want %v", regex, test.rawTarget, test.url, match, test.match)
+		}
+	}
+}
+
+func TestBurpRules(t *testing.T) {
+	tests := []struct {
+		rawTarget string
+		host      string
+		port      string
+		file      string
+		match     bool
+	}{
+		{rawTarget: "*.example.com", host: "api.example.com", match: true},
+		{rawTarget: "*.example.com", host: "example.com", match: false},
+		{rawTarget: "*.example.com", host: "api.example.com.evil.com", match: false},
+		{rawTarget: "example.com:8443", host: "example.com", port: "8443", match: true},
+		{rawTarget: "example.com:8443", host: "example.com", port: "443", match: false},
+		{rawTarget: "https://example.com/api/*", host: "example.com", file: "/api/v1", match: true},
+		{rawTarget: "https://example.com/api/*", host: "example.com", file: "/other", match: false},
+		{rawTarget: "[2001:db8::1]:8443", host: "2001:db8::1", port: "8443", match: true},
+	}
+
+	for _, test := range tests {
+		parsedTarget, ok := parseTarget(test.rawTarget)
+		if !ok {
+			t.Fatalf("parseTarget(%q) failed", test.rawTarget)
+		}
+
+		rule := getBurpRules([]target{parsedTarget})[0]
+
+		match := regexp.MustCompile(rule.Host).MatchString(test.host)
+		if rule.Port != "" {
+			match = match && regexp.MustCompile(rule.Port).MatchString(test.port)
+		}
+		if rule.File != "" {
+			match = match && regexp.MustCompile(rule.File).MatchString(test.file)
+		}
+
+		if match != test.match {
+			t.Errorf("%+v (%q) matches %s:%s%s = %v, want %v", rule, test.rawTarget, test.host, test.port, test.file, match, test.match)
+		}
+	}
+}
+
+func TestGetTargetsSkipsAppCategories(t *testing.T) {
+	programs := []scope.ProgramData{
+		{
+			Url: "https://hackerone.com/acme",
+			InScope: []scope.ScopeElement{
+				{Target: "*.acme.com", Category: "WILDCARD"},
+				{Target: "com.acme.app", Category: "GOOGLE_PLAY_APP_ID"},
+				{Target: "com.acme.wallet", Category: "SMART_CONTRACT"},
+			},
+			OutOfScope: []scope.ScopeElement{
+				{Target: "com.acme.beta", Category: "TESTFLIGHT"},
+				{Target: "blog.acme.com", Category: "URL"},
+			},
+		},
+		{
+			Url: "https://bugcrowd.com/engagements/globex",
+			InScope: []scope.ScopeElement{
+				{Target: "com.globex.ios", Category: "ios"},
+				{Target: "api.globex.com", Category: "api"},
+				{Target: "router.globex.com", Category: "hardware"},
+			},
+		},
+		{
+			Url: "https://app.intigriti.com/researcher/programs/initech/initech/detail",
+			InScope: []scope.ScopeElement{
+				{Target: "com.initech.android", Category: "Android"},
+				{Target: "initech.com", Category: "Url"},
+			},
+		},
+		{
+			Url: "https://api.yeswehack.com/programs/umbrella",
+			InScope: []scope.ScopeElement{
+				{Target: "com.umbrella.app", Category: "mobile-application-android"},
+				{Target: "umbrella.desktop", Category: "application"},
+				{Target: "www.umbrella.com", Category: "web-application"},
+			},
+		},
+		{
+			Url: "https://immunefi.com/bug-bounty/hooli/",
+			InScope: []scope.ScopeElement{
+				{Target: "hooli.eth", Category: "smart_contract"},
+				{Target: "https://app.hooli.xyz", Category: "websites_and_applications"},
+			},
+		},
+	}
+
+	inScope, outOfScope := getTargets(programs, true)
+
+	var hosts []string
+	for _, t := range inScope {
+		hosts = append(hosts, t.host)
+	}
+
+	if got, want := strings.Join(hosts, " "), "*.acme.com api.globex.com initech.com www.umbrella.com app.hooli.xyz"; got != want {
+		t.Errorf("got in scope hosts %q, want %q", got, want)
+	}
+
+	if len(outOfScope) != 1 || outOfScope[0].host != "blog.acme.com" {
+		t.Errorf("got out of scope targets %+v, want blog.acme.com only", outOfScope)
+	}
+
+	var burp bytes.Buffer
+	if err := WriteBurp(&burp, programs, true); err != nil {
+		t.Fatal(err)
+	}
+
+	var zap bytes.Buffer
+	if err := WriteZAP(&zap, programs, "test", true); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, output := range []string{burp.String(), zap.String()} {
+		if strings.Contains(output, `com\\.acme\\.app`) || strings.Contains(output, `com\.acme\.app`) {
+			t.Errorf("bundle ID exported as a host rule: %s", output)
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package export
+
+import (
+	"encoding/xml"
+	"io"
+
+	"github.com/sw33tLie/bbscope/pkg/scope"
+)
+
+// ZAPContext is the context file imported by OWASP ZAP (File > Import Context)
+type ZAPContext struct {
+	XMLName xml.Name `xml:"configuration"`
+	Context struct {
+		Name       string   `xml:"name"`
+		Desc       string   `xml:"desc"`
+		InScope    bool     `xml:"inscope"`
+		IncRegexes []string `xml:"incregexes"`
+		ExcRegexes []string `xml:"excregexes"`
+	} `xml:"context"`
+}
+
+// urlRegex converts a target to a regex matching its URLs, as used by ZAP contexts
+func urlRegex(t target) string {
+	regex := "^https?://"
+	if t.protocol != "" {
+		regex = "^" + t.protocol + "://"
+	}
+
+	regex += urlHostRegex(t.host)
+
+	if t.port != "" {
+		regex += ":" + t.port
+	} else {
+		regex += "(:[0-9]+)?"
+	}
+
+	if t.path != "" {
+		regex += wildcardToRegex(t.path) + ".*"
+	} else {
+		regex += "([/?#].*)?"
+	}
+
+	return regex + "$"
+}
+
+func getURLRegexes(targets []target) (regexes []string) {
+	seen := make(map[string]bool)
+
+	for _, t := range targets {
+		if regex := urlRegex(t); !seen[regex] {
+			seen[regex] = true
+			regexes = append(regexes, regex)
+		}
+	}
+
+	return regexes
+}
+
+// WriteZAP writes an OWASP ZAP context file. Out-of-scope elements become exclude regexes
+func WriteZAP(w io.Writer, programs []scope.ProgramData, contextName string, includeOOS bool) error {
+	inScope, outOfScope := getTargets(programs, includeOOS)
+
+	var zapContext ZAPContext
+	zapContext.Context.Name = contextName
+	zapContext.Context.InScope = true
+	zapContext.Context.IncRegexes = getURLRegexes(inScope)
+	zapContext.Context.ExcRegexes = getURLRegexes(outOfScope)
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(zapContext); err != nil {
+		return err
+	}
+
+	_, err := io.WriteString(w, "\n")
+	return err
+}
@@ -2,16 +2,21 @@ package bugcrowd
 
 import (
 	"crypto/tls"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"log"
 	"net/http"
 	"net/http/cookiejar"
 	"net/url"
+	"os"
+	"path/filepath"
 	"strconv"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/PuerkitoBio/goquery"
 	"github.com/hashicorp/go-retryablehttp"
@@ -28,17 +33,58 @@ const (
 	WAF_BANNED_ERROR = "you are temporarily WAF banned, change IP or wait a few hours"
 )
 
+// ErrWAFBanned is returned when Bugcrowd's WAF blocks our requests
+var ErrWAFBanned = errors.New(WAF_BANNED_ERROR)
+
+// ErrSessionExpired is returned when Bugcrowd rejects the session, or redirects to the login page
+var ErrSessionExpired = errors.New("Bugcrowd session expired")
+
+// Pauses applied after consecutive WAF bans. The last one is repeated
+var wafBackoffSchedule = []time.Duration{5 * time.Minute, 15 * time.Minute, 60 * time.Minute}
+
+// sessionJar records the expiry of the session cookie, which cookie jars don't expose
+type sessionJar struct {
+	http.CookieJar
+	expires time.Time
+}
+
+func (j *sessionJar) SetCookies(u *url.URL, cookies []*http.Cookie) {
+	for _, cookie := range cookies {
+		if cookie.Name != "_bugcrowd_session" {
+			continue
+		}
+
+		switch {
+		case cookie.MaxAge > 0:
+			j.expires = time.Now().Add(time.Duration(cookie.MaxAge) * time.Second)
+		case !cookie.Expires.IsZero():
+			j.expires = cookie.Expires
+		default:
+			j.expires = time.Time{}
+		}
+	}
+
+	j.CookieJar.SetCookies(u, cookies)
+}
+
 // Automated email + password login. 2FA needs to be disabled
 func Login(email, password, proxy string) (string, error) {
+	session, _, err := login(email, password, proxy)
+	return session, err
+}
+
+// login returns the session and its expiry, which is zero if the cookie doesn't have one
+func login(email, password, proxy string) (string, time.Time, error) {
 	cookies := make(map[string]string)
 
 	var loginChallenge string
 
 	// Create a cookie jar
-	jar, err := cookiejar.New(nil)
+	cookieJar, err := cookiejar.New(nil)
 	if err != nil {
-		return "", err
+		return "", time.Time{}, err
 	}
+	jar := &sessionJar{CookieJar: cookieJar}
 
 	// Create a retryablehttp client
 	retryClient := retryablehttp.NewClient()
@@ -46,17 +92,19 @@ func Login(email, password, proxy string) (string, error) {
 	retryClient.Logger = log.New(io.Discard, "", 0)
 
 	retryClient.RetryMax = 5 // Set your retry policy
+	whttp.LimitRequests(retryClient)
 
 	// Set the standard client's cookie jar
 	retryClient.HTTPClient.Jar = jar
 
-	// Set proxy for custom client
+	// Share the default client's transport, unless a proxy is set for the custom client
+	retryClient.HTTPClient.Transport = whttp.GetDefaultClient().HTTPClient.Transport
 
 	if proxy != "" {
 		proxyURL, err := url.Parse(proxy)
 		if err != nil {
 			log.Fatal("Invalid Proxy String")
-			return "", err
+			return "", time.Time{}, err
 		}
 
 		retryClient.HTTPClient.Transport = &http.Transport{
@@ -76,8 +124,8 @@ func Login(email, password, proxy string) (string, error) {
 	// Set the custom redirect policy on the underlying http.Client
 	retryClient.HTTPClient.CheckRedirect = func(req *http.Request, via []*http.Request) error {
 		utils.Log.Debug("Redirecting to: ", req.URL)
-		if strings.Contains(req.URL.String(), "login_challenge") {
-			loginChallenge = strings.Split(req.URL.String(), "=")[1]
+		if challenge := req.URL.Query().Get("login_challenge"); challenge != "" {
+			loginChallenge = challenge
 		}
 		return nil // return nil to follow the redirect
 	}
@@ -92,16 +140,19 @@ func Login(email, password, proxy string) (string, error) {
 		}, retryClient)
 
 	if err != nil {
-		return "", err
+		return "", time.Time{}, err
 	}
 
 	if firstRes.StatusCode == 403 || firstRes.StatusCode == 406 {
-		return "", errors.New(WAF_BANNED_ERROR)
+		return "", time.Time{}, ErrWAFBanned
 	}
 
 	var allCookiesString string
 	for _, cookie := range firstRes.Headers["Set-Cookie"] {
 		split := strings.Split(cookie, ";")
+		if len(split) < 2 {
+			continue
+		}
 		cookies[split[0]] = split[1]
 		allCookiesString += split[0] + "=" + split[1] + "; "
 	}
@@ -129,15 +180,15 @@ func Login(email, password, proxy string) (string, error) {
 		}, retryClient)
 
 	if err != nil {
-		return "", err
+		return "", time.Time{}, err
 	}
 
 	if loginRes.StatusCode == 401 {
-		return "", errors.New("Login failed")
+		return "", time.Time{}, errors.New("Login failed")
 	}
 
 	if loginRes.StatusCode == 403 || loginRes.StatusCode == 406 {
-		return "", errors.New(WAF_BANNED_ERROR)
+		return "", time.Time{}, ErrWAFBanned
 	}
 
 	redirectRes, err := whttp.SendHTTPRequest(
@@ -151,22 +202,133 @@ func Login(email, password, proxy string) (string, error) {
 		}, retryClient)
 
 	if err != nil {
-		return "", err
+		return "", time.Time{}, err
 	}
 
 	if redirectRes.StatusCode == 403 || redirectRes.StatusCode == 406 {
-		return "", errors.New(WAF_BANNED_ERROR)
+		return "", time.Time{}, ErrWAFBanned
 	}
 
 	for _, cookie := range retryClient.HTTPClient.Jar.Cookies(identityUrl) {
 		if cookie.Name == "_bugcrowd_session" {
 			utils.Log.Info("Login OK. Fetching programs, please wait...")
 			utils.Log.Debug("SESSION: ", cookie.Value)
-			return cookie.Value, nil
+			return cookie.Value, jar.expires, nil
 		}
 	}
 
-	return "", errors.New("unknown login error")
+	return "", time.Time{}, errors.New("unknown login error")
+}
+
+type sessionCache struct {
+	Email     string    `json:"email"`
+	Session   string    `json:"session"`
+	SavedAt   time.Time `json:"saved_at"`
+	ExpiresAt time.Time `json:"expires_at,omitempty"`
+}
+
+// readSessionCache returns the session cached in cachePath for email, or an empty string if there is none
+func readSessionCache(email, cachePath string) string {
+	cacheBytes, err := ioutil.ReadFile(cachePath)
+	if err != nil {
+		return ""
+	}
+
+	var cache sessionCache
+	if err := json.Unmarshal(cacheBytes, &cache); err != nil {
+		return ""
+	}
+
+	// Sessions of another account, or cached before accounts were stored, can't be trusted
+	if !strings.EqualFold(cache.Email, email) {
+		utils.Log.Info("Cached session belongs to another account, logging in again")
+		return ""
+	}
+
+	// Sessions whose cookie has no expiry are only checked against Bugcrowd
+	if !cache.ExpiresAt.IsZero() && time.Now().After(cache.ExpiresAt) {
+		utils.Log.Info("Cached session expired, logging in again")
+		return ""
+	}
+
+	return cache.Session
+}
+
+// LoginWithCache reuses the session stored in cachePath as long as it belongs to email and Bugcrowd still accepts it.
+// Otherwise it performs a full login and stores the new session in cachePath
+func LoginWithCache(email, password, proxy, cachePath string) (string, error) {
+	if cachedSession := readSessionCache(email, cachePath); cachedSession != "" {
+		valid, err := isSessionValid(cachedSession)
+		if err != nil {
+			utils.Log.Warn("Failed to check the cached session, logging in again: ", err)
+		} else if valid {
+			utils.Log.Info("Reusing cached session from ", cachePath)
+			return cachedSession, nil
+		} else {
+			utils.Log.Info("Cached session expired, logging in again")
+		}
+	}
+
+	os.Remove(cachePath)
+
+	session, expiresAt, err := login(email, password, proxy)
+	if err != nil {
+		return "", err
+	}
+
+	cacheBytes, err := json.Marshal(sessionCache{Email: email, Session: session, SavedAt: time.Now(), ExpiresAt: expiresAt})
+	if err != nil {
+		return "", err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(cachePath), 0700); err != nil {
+		utils.Log.Warn("Failed to create session cache directory: ", err)
+		return session, nil
+	}
+
+	if err := ioutil.WriteFile(cachePath, cacheBytes, 0600); err != nil {
+		utils.Log.Warn("Failed to write session cache: ", err)
+	}
+
+	return session, nil
+}
+
+// checkResponse returns ErrWAFBanned or ErrSessionExpired for responses that can't be used
+func checkResponse(res *whttp.WHTTPRes) error {
+	if res.StatusCode == 403 || res.StatusCode == 406 {
+		return ErrWAFBanned
+	}
+
+	if res.StatusCode == 401 || strings.HasPrefix(res.FinalURL, "https://identity.bugcrowd.com/") {
+		return ErrSessionExpired
+	}
+
+	return nil
+}
+
+// Checks the session with a cheap authenticated request. Invalid sessions get redirected to the login page
+func isSessionValid(sessionToken string) (bool, error) {
+	res, err := whttp.SendHTTPRequest(
+		&whttp.WHTTPReq{
+			Method: "GET",
+			URL:    "https://bugcrowd.com/engagements.json?category=bug_bounty&page=1",
+			Headers: []whttp.WHTTPHeader{
+				{Name: "Cookie", Value: "_bugcrowd_session=" + sessionToken},
+				{Name: "User-Agent", Value: USER_AGENT},
+			},
+		}, nil)
+
+	if err != nil {
+		return false, err
+	}
+
+	if err := checkResponse(res); errors.Is(err, ErrWAFBanned) {
+		return false, err
+	} else if err != nil {
+		return false, nil
+	}
+
+	return res.StatusCode == 200 && gjson.Get(res.BodyString, "engagements").Exists(), nil
 }
 
 func GetProgramHandles(sessionToken string, engagementType string, pvtOnly bool) ([]string, error) {
@@ -196,8 +358,8 @@ func GetProgramHandles(sessionToken string, engagementType string, pvtOnly bool)
 			return nil, err
 		}
 
-		if res.StatusCode == 403 || res.StatusCode == 406 {
-			return nil, errors.New("you are temporarily WAF banned, change IP or wait a few hours")
+		if err := checkResponse(res); err != nil {
+			return nil, err
 		}
 
 		// Assuming res.BodyString is the JSON string response
@@ -245,6 +407,8 @@ func GetProgramHandles(sessionToken string, engagementType string, pvtOnly bool)
 }
 
 func GetProgramScope(handle string, categories string, token string) (pData scope.ProgramData, err error) {
+	defer scope.RecoverParseError(handle, &err)
+
 	isEngagement := strings.HasPrefix(handle, "/engagements/")
 
 	pData.Url = "https://bugcrowd.com/" + strings.TrimPrefix(handle, "/")
@@ -287,8 +451,8 @@ func getEngagementBriefVersionDocument(handle string, token string) (string, err
 		return "", err
 	}
 
-	if res.StatusCode == 403 || res.StatusCode == 406 {
-		return "", errors.New(WAF_BANNED_ERROR)
+	if err := checkResponse(res); err != nil {
+		return "", err
 	}
 
 	// Likely from a knownHandle we passed that's actually gone now
@@ -322,7 +486,7 @@ func extractScopeFromEngagement(getBriefVersionDocument string, token string, pD
 	if getBriefVersionDocument == ".json" {
 		utils.Log.Warn("Compliance required! Empty Extraction URL (Skipping)...")
 		pData.InScope = append(pData.InScope, scope.ScopeElement{
-			Target:      "2FA_REQUIRED",
+			Target:      scope.TWO_FA_REQUIRED,
 			Description: "Two-Factor Authentication is required to access this program.",
 		})
 		return nil
@@ -342,8 +506,8 @@ func extractScopeFromEngagement(getBriefVersionDocument string, token string, pD
 		return err
 	}
 
-	if res.StatusCode == 403 || res.StatusCode == 406 {
-		return errors.New(WAF_BANNED_ERROR)
+	if err := checkResponse(res); err != nil {
+		return err
 	}
 
 	// Extract the "scope" array from the JSON
@@ -400,8 +564,8 @@ func extractScopeFromTargetGroups(url string, categories string, token string, p
 		return err
 	}
 
-	if res.StatusCode == 403 || res.StatusCode == 406 {
-		return errors.New(WAF_BANNED_ERROR)
+	if err := checkResponse(res); err != nil {
+		return err
 	}
 
 	// Likely from a knownHandle we passed that's actually gone now
@@ -420,7 +584,7 @@ func extractScopeFromTargetGroups(url string, categories string, token string, p
 	}
 
 	if noScopeTable {
-		pData.InScope = append(pData.InScope, scope.ScopeElement{Target: "NO_IN_SCOPE_TABLE", Description: "", Category: ""})
+		pData.InScope = append(pData.InScope, scope.ScopeElement{Target: scope.NO_IN_SCOPE_TABLE, Description: "", Category: ""})
 	}
 
 	return nil
@@ -442,13 +606,21 @@ func extractScopeFromTargetTable(scopeTableURL string, categories string, token
 		return err
 	}
 
-	if res.StatusCode == 403 || res.StatusCode == 406 {
-		return errors.New(WAF_BANNED_ERROR)
+	if err := checkResponse(res); err != nil {
+		return err
 	}
 
 	json := string(res.BodyString)
 	targetsCount := gjson.Get(json, "targets.#").Int()
 
+	var fetchedCategories []string
+	if categories != "all" {
+		fetchedCategories, err = GetCategories(categories)
+		if err != nil {
+			return err
+		}
+	}
+
 	for i := 0; i < int(targetsCount); i++ {
 		targetPath := fmt.Sprintf("targets.%d", i)
 		name := strings.TrimSpace(gjson.Get(json, targetPath+".name").String())
@@ -456,13 +628,8 @@ func extractScopeFromTargetTable(scopeTableURL string, categories string, token
 		category := gjson.Get(json, targetPath+".category").String()
 		description := gjson.Get(json, targetPath+".description").String()
 
-		fetchedCategories, err := GetCategories(categories)
-
-		if err != nil {
-			return err
-		}
-
-		if categories != "all" && category != fetchedCategories[0] {
+		// Some categories (e.g. mobile) map to more than one Bugcrowd category
+		if categories != "all" && !isInArray(category, fetchedCategories) {
 			continue
 		}
 
@@ -504,15 +671,177 @@ func GetCategories(input string) ([]string, error) {
 	return selectedCategory, nil
 }
 
-func GetAllProgramsScope(token string, bbpOnly bool, pvtOnly bool, categories string, outputFlags string, concurrency int, delimiterCharacter string, includeOOS, printRealTime bool, knownHandles []string) (programs []scope.ProgramData, err error) {
-	programHandles, err := GetProgramHandles(token, "bug_bounty", pvtOnly)
+// Function to check if a string is in a slice of strings
+func isInArray(val string, array []string) bool {
+	for _, item := range array {
+		if item == val {
+			return true
+		}
+	}
+	return false
+}
+
+// session shares the token between workers, and logs in again once if it expires during the run
+type session struct {
+	mu      sync.Mutex
+	token   string
+	relogin func() (string, error)
+}
+
+func (s *session) get() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.token
+}
+
+// renew logs in again, unless another worker already replaced expiredToken
+func (s *session) renew(expiredToken string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.token != expiredToken {
+		return nil
+	}
+
+	if s.relogin == nil {
+		return ErrSessionExpired
+	}
+
+	utils.Log.Warn("Bugcrowd session expired, logging in again")
+	token, err := s.relogin()
+	if err != nil {
+		return fmt.Errorf("%w, logging in again failed: %v", ErrSessionExpired, err)
+	}
+
+	s.token = token
+	return nil
+}
+
+// do calls fn with the current token, and once more with a new one if the session expired
+func (s *session) do(fn func(token string) error) error {
+	token := s.get()
+
+	err := fn(token)
+	if !errors.Is(err, ErrSessionExpired) {
+		return err
+	}
+
+	if err := s.renew(token); err != nil {
+		return err
+	}
+
+	return fn(s.get())
+}
+
+// wafBackoff pauses all workers while we are WAF banned
+type wafBackoff struct {
+	mu          sync.Mutex
+	pausedUntil time.Time
+	attempts    int
+	totalWait   time.Duration
+	maxWait     time.Duration
+}
+
+// wait blocks while workers are paused
+func (w *wafBackoff) wait() {
+	w.mu.Lock()
+	delay := time.Until(w.pausedUntil)
+	w.mu.Unlock()
+
+	if delay > 0 {
+		time.Sleep(delay)
+	}
+}
+
+// banned pauses all workers for the next backoff delay.
+// It returns false if that would exceed the maximum total wait
+func (w *wafBackoff) banned() bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	// Another worker already paused everyone
+	if time.Now().Before(w.pausedUntil) {
+		return true
+	}
+
+	delay := wafBackoffSchedule[len(wafBackoffSchedule)-1]
+	if w.attempts < len(wafBackoffSchedule) {
+		delay = wafBackoffSchedule[w.attempts]
+	}
+
+	if w.totalWait+delay > w.maxWait {
+		return false
+	}
+
+	w.attempts++
+	w.totalWait += delay
+	w.pausedUntil = time.Now().Add(delay)
+	utils.Log.Warn("WAF banned by Bugcrowd, pausing all requests for ", delay)
+
+	return true
+}
+
+// reset restarts the backoff schedule after a successful request
+func (w *wafBackoff) reset() {
+	w.mu.Lock()
+	w.attempts = 0
+	w.mu.Unlock()
+}
+
+// do calls fn until it isn't WAF banned, pausing all workers between attempts.
+// It gives up once the total pause would exceed maxWait
+func (w *wafBackoff) do(fn func() error) error {
+	for {
+		w.wait()
+
+		err := fn()
+		if !errors.Is(err, ErrWAFBanned) {
+			if err == nil {
+				w.reset()
+			}
+			return err
+		}
+
+		if !w.banned() {
+			return fmt.Errorf("still WAF banned after waiting up to %v: %w", w.maxWait, err)
+		}
+	}
+}
+
+// GetAllProgramsScope fetches the scope of all programs. When WAF banned, program listing and workers pause
+// with an exponential backoff and retry, giving up once they have waited more than wafMaxWait in total.
+// If the session expires during the run, relogin is called once to get a new one. It may be nil.
+// The first error stops every worker, and the programs fetched until then are returned along with it
+func GetAllProgramsScope(token string, bbpOnly bool, pvtOnly bool, categories string, outputFlags string, concurrency int, delimiterCharacter string, includeOOS, printRealTime bool, knownHandles []string, wafMaxWait time.Duration, relogin func() (string, error)) (programs []scope.ProgramData, err error) {
+	backoff := &wafBackoff{maxWait: wafMaxWait}
+	session := &session{token: token, relogin: relogin}
+
+	var programHandles []string
+	err = backoff.do(func() error {
+		return session.do(func(token string) (err error) {
+			programHandles, err = GetProgramHandles(token, "bug_bounty", pvtOnly)
+			return err
+		})
+	})
 
 	if err != nil {
 		return nil, err
 	}
 
+	bbpHandles := make(map[string]bool)
+	for _, handle := range programHandles {
+		bbpHandles[handle] = true
+	}
+
 	if !bbpOnly {
-		vdpHandles, err := GetProgramHandles(token, "vdp", pvtOnly)
+		var vdpHandles []string
+		err = backoff.do(func() error {
+			return session.do(func(token string) (err error) {
+				vdpHandles, err = GetProgramHandles(token, "vdp", pvtOnly)
+				return err
+			})
+		})
+
 		if err != nil {
 			return nil, err
 		}
@@ -536,22 +865,57 @@ func GetAllProgramsScope(token string, bbpOnly bool, pvtOnly bool, categories st
 	utils.Log.Info("Fetching ", strconv.Itoa(len(programHandles)), " programs...")
 
 	var mutex sync.Mutex
+	var failedPrograms int
 	handles := make(chan string, concurrency)
-	errChan := make(chan error, 1)
 	processGroup := new(sync.WaitGroup)
 
+	// The first error stops the producer and every worker
+	var firstErr error
+	var stopOnce sync.Once
+	stop := make(chan struct{})
+	fail := func(err error) {
+		stopOnce.Do(func() {
+			firstErr = err
+			close(stop)
+		})
+	}
+
 	for i := 0; i < concurrency; i++ {
 		processGroup.Add(1)
 		go func() {
 			defer processGroup.Done()
-			for handle := range handles {
-				pScope, err := GetProgramScope(handle, categories, token)
+			for {
+				var handle string
+				select {
+				case <-stop:
+					return
+				case nextHandle, ok := <-handles:
+					if !ok {
+						return
+					}
+					handle = nextHandle
+				}
+
+				var pScope scope.ProgramData
+				err := backoff.do(func() error {
+					return session.do(func(token string) (err error) {
+						pScope, err = GetProgramScope(handle, categories, token)
+						return err
+					})
+				})
+
+				// A single malformed program must not abort the whole run
+				var parseErr *scope.ParseError
+				if errors.As(err, &parseErr) {
+					utils.Log.Warn(parseErr)
+					mutex.Lock()
+					failedPrograms++
+					mutex.Unlock()
+					continue
+				}
 
 				if err != nil {
-					select {
-					case errChan <- fmt.Errorf("error processing handle %s: %v", handle, err):
-					default:
-					}
+					fail(fmt.Errorf("error processing handle %s: %w", handle, err))
 					return
 				}
 
@@ -559,6 +923,8 @@ func GetAllProgramsScope(token string, bbpOnly bool, pvtOnly bool, categories st
 					continue
 				}
 
+				pScope.IsBBP = bbpHandles[handle]
+
 				mutex.Lock()
 				programs = append(programs, pScope)
 				mutex.Unlock()
@@ -571,19 +937,25 @@ func GetAllProgramsScope(token string, bbpOnly bool, pvtOnly bool, categories st
 	}
 
 	go func() {
+		defer close(handles)
 		for _, handle := range programHandles {
-			handles <- handle
+			select {
+			case handles <- handle:
+			case <-stop:
+				return
+			}
 		}
-		close(handles)
 	}()
 
-	go func() {
-		processGroup.Wait()
-		close(errChan)
-	}()
+	// Wait for every worker, so that programs isn't modified after being returned
+	processGroup.Wait()
+
+	if firstErr != nil {
+		return programs, firstErr // Return partial results and the error
+	}
 
-	if err := <-errChan; err != nil {
-		return programs, err // Return partial results and the error
+	if failedPrograms > 0 {
+		utils.Log.Warn("Failed to parse the scope of ", failedPrograms, " programs")
 	}
 
 	return programs, nil
@@ -0,0 +1,523 @@
+package bugcrowd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/sw33tLie/bbscope/internal/testutil"
+	"github.com/sw33tLie/bbscope/pkg/whttp"
+)
+
+// useTestServer routes the default whttp client to handler for the duration of the test
+func useTestServer(t testing.TB, handler http.Handler) {
+	testutil.UseTestServer(t, handler)
+
+	// Don't wait for the default bugcrowd.com rate limit
+	whttp.SetRateLimit("bugcrowd.com", 0, 0)
+	t.Cleanup(func() {
+		whttp.SetRateLimit("bugcrowd.com", 1, 1)
+	})
+}
+
+const (
+	testPages        = 3
+	testPageSize     = 10
+	testSessionToken = "test-session"
+)
+
+// engagementsHandler serves testPages pages of testPageSize engagements. Odd engagements are public
+func engagementsHandler(t *testing.T, requestedPages *[]int, mu *sync.Mutex) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/engagements.json" {
+			http.NotFound(w, r)
+			return
+		}
+
+		if cookie, err := r.Cookie("_bugcrowd_session"); err != nil || cookie.Value != testSessionToken {
+			t.Errorf("missing session cookie, got %q", r.Header.Get("Cookie"))
+		}
+
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+
+		mu.Lock()
+		*requestedPages = append(*requestedPages, page)
+		mu.Unlock()
+
+		engagements := ""
+		if page >= 1 && page <= testPages {
+			for i := 0; i < testPageSize; i++ {
+				id := (page-1)*testPageSize + i
+				accessStatus := "invited"
+				if id%2 == 1 {
+					accessStatus = "open"
+				}
+
+				if engagements != "" {
+					engagements += ","
+				}
+				engagements += fmt.Sprintf(`{"briefUrl":"/engagements/program-%d","accessStatus":"%s"}`, id, accessStatus)
+			}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"engagements":[%s],"paginationMeta":{"totalCount":%d}}`, engagements, testPages*testPageSize)
+	}
+}
+
+func TestGetProgramHandlesPagination(t *testing.T) {
+	var requestedPages []int
+	var mu sync.Mutex
+	useTestServer(t, engagementsHandler(t, &requestedPages, &mu))
+
+	handles, err := GetProgramHandles(testSessionToken, "bug_bounty", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(handles) != testPages*testPageSize {
+		t.Fatalf("got %d handles, want %d", len(handles), testPages*testPageSize)
+	}
+
+	for i, handle := range handles {
+		if want := fmt.Sprintf("/engagements/program-%d", i); handle != want {
+			t.Errorf("handle %d = %q, want %q", i, handle, want)
+		}
+	}
+
+	if fmt.Sprint(requestedPages) != "[1 2 3]" {
+		t.Errorf("requested pages %v, want [1 2 3]", requestedPages)
+	}
+}
+
+func TestGetProgramHandlesPrivateOnly(t *testing.T) {
+	var requestedPages []int
+	var mu sync.Mutex
+	useTestServer(t, engagementsHandler(t, &requestedPages, &mu))
+
+	handles, err := GetProgramHandles(testSessionToken, "bug_bounty", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(handles) != testPages*testPageSize/2 {
+		t.Fatalf("got %d handles, want %d", len(handles), testPages*testPageSize/2)
+	}
+
+	for _, handle := range handles {
+		id, _ := strconv.Atoi(handle[len("/engagements/program-"):])
+		if id%2 == 1 {
+			t.Errorf("public program %q returned with pvtOnly", handle)
+		}
+	}
+}
+
+func TestGetProgramHandlesWAFBanned(t *testing.T) {
+	useTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	}))
+
+	if _, err := GetProgramHandles(testSessionToken, "bug_bounty", false); !errors.Is(err, ErrWAFBanned) {
+		t.Fatalf("got error %v, want ErrWAFBanned", err)
+	}
+}
+
+// Target categories returned by Bugcrowd's target tables
+var knownTargetCategories = []string{"website", "api", "android", "ios", "other", "hardware"}
+
+func TestEveryTargetCategoryIsReachable(t *testing.T) {
+	names := []string{"url", "api", "mobile", "android", "apple", "other", "hardware"}
+	testutil.CheckCategoriesReachable(t, knownTargetCategories, names, func(name string) []string {
+		categories, err := GetCategories(name)
+		if err != nil {
+			t.Fatalf("category %s: %v", name, err)
+		}
+		return categories
+	})
+
+	if _, err := GetCategories("invalid"); err == nil {
+		t.Error("invalid category should return an error")
+	}
+}
+
+func writeSessionCache(t *testing.T, cache sessionCache) string {
+	cachePath := filepath.Join(t.TempDir(), "bugcrowd.json")
+
+	cacheBytes, err := json.Marshal(cache)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := ioutil.WriteFile(cachePath, cacheBytes, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	return cachePath
+}
+
+func TestReadSessionCache(t *testing.T) {
+	tests := []struct {
+		name  string
+		cache sessionCache
+		email string
+		want  string
+	}{
+		{"same account", sessionCache{Email: "hunter@example.com", Session: "cached"}, "hunter@example.com", "cached"},
+		{"same account, other case", sessionCache{Email: "Hunter@Example.com", Session: "cached"}, "hunter@example.com", "cached"},
+		{"other account", sessionCache{Email: "other@example.com", Session: "cached"}, "hunter@example.com", ""},
+		{"cache without account", sessionCache{Session: "cached"}, "hunter@example.com", ""},
+		{"expired cookie", sessionCache{Email: "hunter@example.com", Session: "cached", ExpiresAt: time.Now().Add(-time.Minute)}, "hunter@example.com", ""},
+		{"unexpired cookie", sessionCache{Email: "hunter@example.com", Session: "cached", ExpiresAt: time.Now().Add(time.Hour)}, "hunter@example.com", "cached"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := readSessionCache(test.email, writeSessionCache(t, test.cache)); got != test.want {
+				t.Errorf("got session %q, want %q", got, test.want)
+			}
+		})
+	}
+
+	if got := readSessionCache("hunter@example.com", filepath.Join(t.TempDir(), "missing.json")); got != "" {
+		t.Errorf("got session %q from a missing cache", got)
+	}
+}
+
+func TestLoginWithCacheReusesValidSession(t *testing.T) {
+	var requestedPages []int
+	var mu sync.Mutex
+	useTestServer(t, engagementsHandler(t, &requestedPages, &mu))
+
+	cachePath := writeSessionCache(t, sessionCache{Email: "hunter@example.com", Session: testSessionToken})
+
+	session, err := LoginWithCache("hunter@example.com", "password", "", cachePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if session != testSessionToken {
+		t.Errorf("got session %q, want the cached one", session)
+	}
+}
+
+func FuzzGetProgramScope(f *testing.F) {
+	f.Add(false, []byte(`{"groups":[{"targets_url":"/acme/targets","in_scope":true}],"targets":[{"name":"Acme","uri":"https://acme.com","category":"website"}]}`))
+	f.Add(false, []byte(`{"groups":[]}`))
+	f.Add(true, []byte(`<div data-react-class="ResearcherEngagementBrief" data-api-endpoints='{"engagementBriefApi":{"getBriefVersionDocument":"/brief"}}'></div>`))
+	f.Add(true, []byte(`{"data":{"scope":[{"inScope":true,"targets":[{"name":"Acme","category":"website"}]}]}}`))
+
+	body := new(testutil.Body)
+	useTestServer(f, body)
+
+	f.Fuzz(func(t *testing.T, engagement bool, data []byte) {
+		body.Set(data)
+
+		handle := "/acme"
+		if engagement {
+			handle = "/engagements/acme"
+		}
+
+		_, err := GetProgramScope(handle, "all", testSessionToken)
+		testutil.FailOnParseError(t, err)
+	})
+}
+
+const (
+	testEmail          = "hunter@example.com"
+	testPassword       = "hunter2"
+	testCSRFToken      = "test-csrf"
+	testLoginChallenge = "test-challenge"
+	testSessionMaxAge  = 3600
+)
+
+// loginHandler mimics Bugcrowd's identity flow, then serves engagements to sessions it issued
+func loginHandler(t *testing.T, logins *int) http.Handler {
+	engagements := engagementsHandler(t, new([]int), new(sync.Mutex))
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Host == "identity.bugcrowd.com" && r.Method == "GET" && r.URL.Path == "/login":
+			http.SetCookie(w, &http.Cookie{Name: "csrf-token", Value: testCSRFToken, Path: "/"})
+			http.Redirect(w, r, "/login/researcher?login_challenge="+testLoginChallenge, http.StatusFound)
+
+		case r.Host == "identity.bugcrowd.com" && r.URL.Path == "/login/researcher":
+			fmt.Fprint(w, "<html><title>Login</title></html>")
+
+		case r.Host == "identity.bugcrowd.com" && r.Method == "POST" && r.URL.Path == "/login":
+			if r.Header.Get("X-Csrf-Token") != testCSRFToken {
+				t.Errorf("got CSRF token %q, want %q", r.Header.Get("X-Csrf-Token"), testCSRFToken)
+			}
+
+			r.ParseForm()
+			if r.PostForm.Get("login_challenge") != testLoginChallenge {
+				t.Errorf("got login challenge %q, want %q", r.PostForm.Get("login_challenge"), testLoginChallenge)
+			}
+
+			if r.PostForm.Get("username") != testEmail || r.PostForm.Get("password") != testPassword {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+
+			*logins++
+			fmt.Fprint(w, `{"redirect_to":"https://bugcrowd.com/dashboard"}`)
+
+		case r.Host == "bugcrowd.com" && r.URL.Path == "/dashboard":
+			http.SetCookie(w, &http.Cookie{Name: "_bugcrowd_session", Value: testSessionToken, Domain: "bugcrowd.com", Path: "/", MaxAge: testSessionMaxAge})
+			fmt.Fprint(w, "<html><title>Dashboard</title></html>")
+
+		case r.Host == "bugcrowd.com" && (r.URL.Path == "/engagements.json" || strings.HasPrefix(r.URL.Path, "/engagements/")):
+			// Invalid sessions are redirected to the login page
+			if cookie, err := r.Cookie("_bugcrowd_session"); err != nil || cookie.Value != testSessionToken {
+				http.Redirect(w, r, "https://identity.bugcrowd.com/login/researcher", http.StatusFound)
+				return
+			}
+			engagements(w, r)
+
+		default:
+			t.Errorf("unexpected request to %s%s", r.Host, r.URL.Path)
+			http.NotFound(w, r)
+		}
+	})
+}
+
+func TestLogin(t *testing.T) {
+	var logins int
+	useTestServer(t, loginHandler(t, &logins))
+
+	session, err := Login(testEmail, testPassword, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if session != testSessionToken || logins != 1 {
+		t.Errorf("got session %q after %d logins, want %q after 1", session, logins, testSessionToken)
+	}
+}
+
+func TestLoginWrongPassword(t *testing.T) {
+	var logins int
+	useTestServer(t, loginHandler(t, &logins))
+
+	if session, err := Login(testEmail, "wrong", ""); err == nil {
+		t.Errorf("got session %q, want an error", session)
+	}
+}
+
+func TestLoginWithCache(t *testing.T) {
+	tests := []struct {
+		name   string
+		cache  *sessionCache
+		logins int
+	}{
+		{"no cache", nil, 1},
+		{"valid session", &sessionCache{Email: testEmail, Session: testSessionToken}, 0},
+		{"expired session", &sessionCache{Email: testEmail, Session: "expired"}, 1},
+		{"other account", &sessionCache{Email: "other@example.com", Session: testSessionToken}, 1},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var logins int
+			useTestServer(t, loginHandler(t, &logins))
+
+			cachePath := filepath.Join(t.TempDir(), "sessions", "bugcrowd.json")
+			if test.cache != nil {
+				cachePath = writeSessionCache(t, *test.cache)
+			}
+
+			session, err := LoginWithCache(testEmail, testPassword, "", cachePath)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if session != testSessionToken || logins != test.logins {
+				t.Errorf("got session %q after %d logins, want %q after %d", session, logins, testSessionToken, test.logins)
+			}
+
+			if got := readSessionCache(testEmail, cachePath); got != testSessionToken {
+				t.Errorf("cached session %q, want %q", got, testSessionToken)
+			}
+
+			if test.logins == 0 {
+				return
+			}
+
+			cacheBytes, err := ioutil.ReadFile(cachePath)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var cache sessionCache
+			if err := json.Unmarshal(cacheBytes, &cache); err != nil {
+				t.Fatal(err)
+			}
+
+			// The expiry comes from the cookie's Max-Age
+			if wantExpiry := time.Now().Add(testSessionMaxAge * time.Second); cache.ExpiresAt.Before(wantExpiry.Add(-time.Minute)) || cache.ExpiresAt.After(wantExpiry) {
+				t.Errorf("cached expiry %v, want about %v", cache.ExpiresAt, wantExpiry)
+			}
+		})
+	}
+}
+
+func TestCheckResponse(t *testing.T) {
+	tests := []struct {
+		res  whttp.WHTTPRes
+		want error
+	}{
+		{whttp.WHTTPRes{StatusCode: 200, FinalURL: "https://bugcrowd.com/engagements.json"}, nil},
+		{whttp.WHTTPRes{StatusCode: 404, FinalURL: "https://bugcrowd.com/acme"}, nil},
+		{whttp.WHTTPRes{StatusCode: 403}, ErrWAFBanned},
+		{whttp.WHTTPRes{StatusCode: 406}, ErrWAFBanned},
+		{whttp.WHTTPRes{StatusCode: 401}, ErrSessionExpired},
+		{whttp.WHTTPRes{StatusCode: 200, FinalURL: "https://identity.bugcrowd.com/login/researcher"}, ErrSessionExpired},
+	}
+
+	for _, test := range tests {
+		if err := checkResponse(&test.res); err != test.want {
+			t.Errorf("checkResponse(%d, %q) = %v, want %v", test.res.StatusCode, test.res.FinalURL, err, test.want)
+		}
+	}
+}
+
+func TestGetAllProgramsScopeRenewsExpiredSession(t *testing.T) {
+	var logins int
+	useTestServer(t, loginHandler(t, &logins))
+
+	relogin := func() (string, error) {
+		return Login(testEmail, testPassword, "")
+	}
+
+	if _, err := GetAllProgramsScope("expired", true, false, "all", "t", 2, " ", false, false, nil, time.Second, relogin); err != nil {
+		t.Fatal(err)
+	}
+
+	if logins != 1 {
+		t.Errorf("logged in %d times, want 1", logins)
+	}
+}
+
+func TestGetAllProgramsScopeExpiredSessionWithoutRelogin(t *testing.T) {
+	var logins int
+	useTestServer(t, loginHandler(t, &logins))
+
+	if _, err := GetAllProgramsScope("expired", true, false, "all", "t", 2, " ", false, false, nil, time.Second, nil); !errors.Is(err, ErrSessionExpired) {
+		t.Fatalf("got %v, want ErrSessionExpired", err)
+	}
+}
+
+func TestSessionRenewsOnce(t *testing.T) {
+	var logins int32
+	s := &session{token: "expired", relogin: func() (string, error) {
+		atomic.AddInt32(&logins, 1)
+		return testSessionToken, nil
+	}}
+
+	// Every worker sees the expired session, but only the first one logs in again
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := s.do(func(token string) error {
+				if token != testSessionToken {
+					return ErrSessionExpired
+				}
+				return nil
+			})
+			if err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if logins != 1 {
+		t.Errorf("logged in %d times, want 1", logins)
+	}
+}
+
+// useShortWAFBackoff makes WAF ban pauses last a millisecond for the duration of the test
+func useShortWAFBackoff(t *testing.T) {
+	originalSchedule := wafBackoffSchedule
+	wafBackoffSchedule = []time.Duration{time.Millisecond}
+	t.Cleanup(func() {
+		wafBackoffSchedule = originalSchedule
+	})
+}
+
+func TestGetAllProgramsScopeRetriesBannedListing(t *testing.T) {
+	useShortWAFBackoff(t)
+
+	var requestedPages []int
+	var mu sync.Mutex
+	engagements := engagementsHandler(t, &requestedPages, &mu)
+
+	var bans int32
+	useTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/engagements.json" && atomic.AddInt32(&bans, 1) <= 2 {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		engagements(w, r)
+	}))
+
+	if _, err := GetAllProgramsScope(testSessionToken, true, false, "all", "t", 2, " ", false, false, nil, time.Second, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	if fmt.Sprint(requestedPages) != "[1 2 3]" {
+		t.Errorf("requested pages %v after the ban, want [1 2 3]", requestedPages)
+	}
+}
+
+func TestGetAllProgramsScopeStopsOnPersistentBan(t *testing.T) {
+	useShortWAFBackoff(t)
+
+	var requestedPages []int
+	var mu sync.Mutex
+	engagements := engagementsHandler(t, &requestedPages, &mu)
+
+	var scopeRequests int32
+	useTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/engagements.json" {
+			engagements(w, r)
+			return
+		}
+
+		// Some programs succeed before the ban, so that workers keep appending while others fail
+		if atomic.AddInt32(&scopeRequests, 1) <= 3 {
+			http.NotFound(w, r)
+			return
+		}
+		w.WriteHeader(http.StatusForbidden)
+	}))
+
+	done := make(chan error)
+	go func() {
+		_, err := GetAllProgramsScope(testSessionToken, true, false, "all", "t", 4, " ", false, false, nil, 5*time.Millisecond, nil)
+		done <- err
+	}()
+
+	select {
+	case err := <-done:
+		if !errors.Is(err, ErrWAFBanned) {
+			t.Fatalf("got %v, want ErrWAFBanned", err)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("GetAllProgramsScope did not return")
+	}
+
+	if requests := atomic.LoadInt32(&scopeRequests); requests >= testPages*testPageSize {
+		t.Errorf("got %d scope requests, want the run to stop before fetching every program", requests)
+	}
+}
@@ -0,0 +1,145 @@
+package cobalt
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/sw33tLie/bbscope/internal/utils"
+	"github.com/sw33tLie/bbscope/pkg/scope"
+	"github.com/sw33tLie/bbscope/pkg/whttp"
+	"github.com/tidwall/gjson"
+)
+
+const (
+	COBALT_API_URL = "https://api.cobalt.io"
+	COBALT_APP_URL = "https://app.cobalt.io"
+)
+
+// Sends an authenticated request to the Cobalt API. pageURL can be a full URL or a path
+func sendAPIRequest(token string, orgToken string, pageURL string) (*whttp.WHTTPRes, error) {
+	if !strings.HasPrefix(pageURL, "http") {
+		pageURL = COBALT_API_URL + pageURL
+	}
+
+	headers := []whttp.WHTTPHeader{
+		{Name: "Accept", Value: "application/vnd.cobalt.v2+json"},
+		{Name: "Authorization", Value: "Bearer " + token},
+	}
+
+	if orgToken != "" {
+		headers = append(headers, whttp.WHTTPHeader{Name: "X-Org-Token", Value: orgToken})
+	}
+
+	res, err := whttp.SendHTTPRequest(
+		&whttp.WHTTPReq{
+			Method:  "GET",
+			URL:     pageURL,
+			Headers: headers,
+		}, nil)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if res.StatusCode == 401 {
+		return nil, errors.New("invalid Cobalt API token")
+	}
+
+	if res.StatusCode != 200 {
+		return nil, fmt.Errorf("unexpected status code %d for %s", res.StatusCode, pageURL)
+	}
+
+	return res, nil
+}
+
+// GetOrgTokens returns the tokens of all organizations the API token has access to
+func GetOrgTokens(token string) (orgTokens []string, err error) {
+	nextPage := "/orgs"
+
+	for nextPage != "" {
+		res, err := sendAPIRequest(token, "", nextPage)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, org := range gjson.Get(res.BodyString, "data").Array() {
+			if orgToken := org.Get("resource.token").Str; orgToken != "" {
+				orgTokens = append(orgTokens, orgToken)
+			}
+		}
+
+		nextPage = gjson.Get(res.BodyString, "pagination.next_page").Str
+	}
+
+	return orgTokens, nil
+}
+
+// GetOrgScope returns one program per asset of the organization.
+// Assets only have a title and a description, not a host or URL, so they have no Target
+func GetOrgScope(token string, orgToken string) (programs []scope.ProgramData, err error) {
+	nextPage := "/assets?limit=100"
+
+	for nextPage != "" {
+		res, err := sendAPIRequest(token, orgToken, nextPage)
+		if err != nil {
+			return programs, err
+		}
+
+		for _, asset := range gjson.Get(res.BodyString, "data").Array() {
+			pData := scope.ProgramData{
+				Url: asset.Get("links.ui.url").Str,
+			}
+
+			if pData.Url == "" {
+				pData.Url = COBALT_APP_URL
+			}
+
+			description := asset.Get("resource.title").Str
+			if assetDescription := asset.Get("resource.description").Str; assetDescription != "" {
+				description += ": " + assetDescription
+			}
+
+			pData.InScope = append(pData.InScope, scope.ScopeElement{
+				Description: strings.ReplaceAll(description, "\n", "  "),
+				Category:    asset.Get("resource.asset_type").Str,
+			})
+
+			programs = append(programs, pData)
+		}
+
+		nextPage = gjson.Get(res.BodyString, "pagination.next_page").Str
+	}
+
+	return programs, nil
+}
+
+// GetAllProgramsScope fetches the assets of orgToken, or of every organization if orgToken is empty
+func GetAllProgramsScope(token string, orgToken string, outputFlags string, delimiter string, printRealTime bool) (programs []scope.ProgramData, err error) {
+	orgTokens := []string{orgToken}
+
+	if orgToken == "" {
+		utils.Log.Debug("Fetching list of organizations")
+		orgTokens, err = GetOrgTokens(token)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	for _, currentOrgToken := range orgTokens {
+		orgPrograms, err := GetOrgScope(token, currentOrgToken)
+		if err != nil {
+			return programs, err
+		}
+
+		for _, pData := range orgPrograms {
+			if printRealTime {
+				scope.PrintProgramScope(pData, outputFlags, delimiter, false)
+			}
+		}
+
+		programs = append(programs, orgPrograms...)
+	}
+
+	return programs, nil
+}
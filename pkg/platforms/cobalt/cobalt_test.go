@@ -0,0 +1,118 @@
+package cobalt
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+	"testing"
+
+	"github.com/sw33tLie/bbscope/internal/testutil"
+)
+
+// cobaltHandler serves two pages of organizations, and the assets of each organization from testdata
+func cobaltHandler(t *testing.T, requests *[]string, mu *sync.Mutex) http.Handler {
+	fixtures := map[string]string{
+		"/orgs":                      "orgs_page1.json",
+		"/orgs?cursor=page2":         "orgs_page2.json",
+		"org-acme /assets?limit=100": "assets_acme_page1.json",
+		"org-acme /assets?limit=100&cursor=page2": "assets_acme_page2.json",
+		"org-globex /assets?limit=100":            "assets_globex.json",
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		if r.Header.Get("Accept") != "application/vnd.cobalt.v2+json" {
+			t.Errorf("got Accept header %q", r.Header.Get("Accept"))
+		}
+
+		request := r.URL.RequestURI()
+		if orgToken := r.Header.Get("X-Org-Token"); orgToken != "" {
+			request = orgToken + " " + request
+		}
+
+		mu.Lock()
+		*requests = append(*requests, request)
+		mu.Unlock()
+
+		fixture, ok := fixtures[request]
+		if !ok {
+			t.Errorf("unexpected request %q", request)
+			http.NotFound(w, r)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(testutil.ReadFixture(t, fixture))
+	})
+}
+
+func TestGetAllProgramsScope(t *testing.T) {
+	var requests []string
+	var mu sync.Mutex
+	testutil.UseTestServer(t, cobaltHandler(t, &requests, &mu))
+
+	programs, err := GetAllProgramsScope("token", "", "t", " ", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []struct {
+		url         string
+		description string
+		category    string
+	}{
+		{"https://app.cobalt.io/acme/assets/as_web", "Acme web app: Customer portal  and admin panel", "web"},
+		{"https://app.cobalt.io/acme/assets/as_api", "Acme API", "api"},
+		{COBALT_APP_URL, "Globex mobile: iOS and Android apps", "mobile"},
+	}
+
+	if len(programs) != len(want) {
+		t.Fatalf("got %d programs, want %d: %v", len(programs), len(want), programs)
+	}
+
+	for i, pData := range programs {
+		if pData.Url != want[i].url || len(pData.InScope) != 1 {
+			t.Errorf("program %d = %+v, want URL %s with one asset", i, pData, want[i].url)
+			continue
+		}
+
+		asset := pData.InScope[0]
+		if asset.Target != "" || asset.Description != want[i].description || asset.Category != want[i].category {
+			t.Errorf("asset %d = %+v, want description %q and category %q without target", i, asset, want[i].description, want[i].category)
+		}
+	}
+
+	wantRequests := "[/orgs /orgs?cursor=page2 org-acme /assets?limit=100 org-acme /assets?limit=100&cursor=page2 org-globex /assets?limit=100]"
+	if fmt.Sprint(requests) != wantRequests {
+		t.Errorf("got requests %v, want %s", requests, wantRequests)
+	}
+}
+
+func TestGetAllProgramsScopeSingleOrg(t *testing.T) {
+	var requests []string
+	var mu sync.Mutex
+	testutil.UseTestServer(t, cobaltHandler(t, &requests, &mu))
+
+	programs, err := GetAllProgramsScope("token", "org-globex", "t", " ", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(programs) != 1 || fmt.Sprint(requests) != "[org-globex /assets?limit=100]" {
+		t.Errorf("got %d programs after requests %v, want only the globex assets", len(programs), requests)
+	}
+}
+
+func TestGetAllProgramsScopeInvalidToken(t *testing.T) {
+	var requests []string
+	var mu sync.Mutex
+	testutil.UseTestServer(t, cobaltHandler(t, &requests, &mu))
+
+	if _, err := GetAllProgramsScope("wrong", "", "t", " ", false); err == nil {
+		t.Error("expected an error for an invalid token")
+	}
+}
@@ -15,7 +15,18 @@ import (
 	"github.com/tidwall/gjson"
 )
 
-func getProgramScope(authorization string, id string, bbpOnly bool, categories []string, includeOOS bool) (pData scope.ProgramData, err error) {
+// Severities accepted by HackerOne's max_severity attribute, from lowest to highest
+var severityRanks = map[string]int{
+	"none":     0,
+	"low":      1,
+	"medium":   2,
+	"high":     3,
+	"critical": 4,
+}
+
+func getProgramScope(authorization string, id string, bbpOnly bool, categories []string, minSeverity string, includeOOS bool) (pData scope.ProgramData, err error) {
+	defer scope.RecoverParseError(id, &err)
+
 	pData.Url = "https://hackerone.com/" + id
 	currentPageURL := "https://api.hackerone.com/v1/hackers/programs/" + id + "/structured_scopes?page%5Bnumber%5D=1&page%5Bsize%5D=100"
 
@@ -74,12 +85,19 @@ func getProgramScope(authorization string, id string, bbpOnly bool, categories [
 				eligibleForBounty := gjson.Get(res.BodyString, "data."+strconv.Itoa(i)+".attributes.eligible_for_bounty").Bool()
 				eligibleForSubmission := gjson.Get(res.BodyString, "data."+strconv.Itoa(i)+".attributes.eligible_for_submission").Bool()
 
+				// Assets without a max severity accept everything
+				maxSeverity := gjson.Get(res.BodyString, "data."+strconv.Itoa(i)+".attributes.max_severity").Str
+				if maxSeverity == "" {
+					maxSeverity = "critical"
+				}
+
 				if eligibleForSubmission {
-					if !bbpOnly || (bbpOnly && eligibleForBounty) {
+					if (!bbpOnly || (bbpOnly && eligibleForBounty)) && severityRanks[maxSeverity] >= severityRanks[minSeverity] {
 						pData.InScope = append(pData.InScope, scope.ScopeElement{
 							Target:      gjson.Get(res.BodyString, "data."+strconv.Itoa(i)+".attributes.asset_identifier").Str,
 							Description: strings.ReplaceAll(gjson.Get(res.BodyString, "data."+strconv.Itoa(i)+".attributes.instruction").Str, "\n", "  "),
 							Category:    gjson.Get(res.BodyString, "data."+strconv.Itoa(i)+".attributes.asset_type").Str,
+							MaxSeverity: maxSeverity,
 						})
 					}
 				} else {
@@ -88,6 +106,7 @@ func getProgramScope(authorization string, id string, bbpOnly bool, categories [
 							Target:      gjson.Get(res.BodyString, "data."+strconv.Itoa(i)+".attributes.asset_identifier").Str,
 							Description: strings.ReplaceAll(gjson.Get(res.BodyString, "data."+strconv.Itoa(i)+".attributes.instruction").Str, "\n", "  "),
 							Category:    gjson.Get(res.BodyString, "data."+strconv.Itoa(i)+".attributes.asset_type").Str,
+							MaxSeverity: maxSeverity,
 						})
 					}
 				}
@@ -99,8 +118,9 @@ func getProgramScope(authorization string, id string, bbpOnly bool, categories [
 			pData.OutOfScope = []scope.ScopeElement{}
 		}
 
-		if l == 0 {
-			pData.InScope = append(pData.InScope, scope.ScopeElement{Target: "NO_IN_SCOPE_TABLE", Description: "", Category: ""})
+		// Programs without a scope table have nothing eligible for a bounty
+		if l == 0 && !bbpOnly {
+			pData.InScope = append(pData.InScope, scope.ScopeElement{Target: scope.NO_IN_SCOPE_TABLE, Description: "", Category: ""})
 		}
 
 		nextPageURL := gjson.Get(res.BodyString, "links.next")
@@ -123,9 +143,9 @@ func getCategories(input string) []string {
 	categories := map[string][]string{
 		"url":        {"URL", "WILDCARD", "IP_ADDRESS"},
 		"cidr":       {"CIDR"},
-		"mobile":     {"GOOGLE_PLAY_APP_ID", "OTHER_APK", "APPLE_STORE_APP_ID"},
+		"mobile":     {"GOOGLE_PLAY_APP_ID", "OTHER_APK", "APPLE_STORE_APP_ID", "OTHER_IPA", "TESTFLIGHT"},
 		"android":    {"GOOGLE_PLAY_APP_ID", "OTHER_APK"},
-		"apple":      {"APPLE_STORE_APP_ID", "TESTFLIGHT"},
+		"apple":      {"APPLE_STORE_APP_ID", "OTHER_IPA", "TESTFLIGHT"},
 		"ai":         {"AI_MODEL"},
 		"other":      {"OTHER"},
 		"hardware":   {"HARDWARE"},
@@ -142,7 +162,8 @@ func getCategories(input string) []string {
 	return selectedCategory
 }
 
-func getProgramHandles(authorization string, pvtOnly bool, publicOnly bool, active bool) (handles []string) {
+func getProgramHandles(authorization string, bbpOnly bool, pvtOnly bool, publicOnly bool, active bool) (handles []string, offersBounties map[string]bool) {
+	offersBounties = make(map[string]bool)
 	currentURL := "https://api.hackerone.com/v1/hackers/programs?page%5Bsize%5D=100"
 	for {
 		res, err := whttp.SendHTTPRequest(
@@ -166,6 +187,12 @@ func getProgramHandles(authorization string, pvtOnly bool, publicOnly bool, acti
 
 		for i := 0; i < int(gjson.Get(res.BodyString, "data.#").Int()); i++ {
 			handle := gjson.Get(res.BodyString, "data."+strconv.Itoa(i)+".attributes.handle")
+			offersBounties[handle.Str] = gjson.Get(res.BodyString, "data."+strconv.Itoa(i)+".attributes.offers_bounties").Bool()
+
+			// VDPs can have targets marked as eligible for bounty, so they must be excluded here
+			if bbpOnly && !offersBounties[handle.Str] {
+				continue
+			}
 
 			if !publicOnly {
 				if !pvtOnly || (pvtOnly && gjson.Get(res.BodyString, "data."+strconv.Itoa(i)+".attributes.state").Str == "soft_launched") {
@@ -198,12 +225,21 @@ func getProgramHandles(authorization string, pvtOnly bool, publicOnly bool, acti
 		}
 	}
 
-	return handles
+	return handles, offersBounties
 }
 
-func GetAllProgramsScope(authorization string, bbpOnly bool, pvtOnly bool, publicOnly bool, categories string, active bool, concurrency int, printRealTime bool, outputFlags string, delimiter string, includeOOS bool) (programs []scope.ProgramData, err error) {
+func GetAllProgramsScope(authorization string, bbpOnly bool, pvtOnly bool, publicOnly bool, categories string, minSeverity string, active bool, concurrency int, printRealTime bool, outputFlags string, delimiter string, includeOOS bool) (programs []scope.ProgramData, err error) {
+	if minSeverity == "" {
+		minSeverity = "none"
+	}
+
+	if _, ok := severityRanks[strings.ToLower(minSeverity)]; !ok {
+		utils.Log.Fatal("Invalid severity selected: ", minSeverity)
+	}
+	minSeverity = strings.ToLower(minSeverity)
+
 	utils.Log.Debug("Fetching list of program handles")
-	programHandles := getProgramHandles(authorization, pvtOnly, publicOnly, active)
+	programHandles, offersBounties := getProgramHandles(authorization, bbpOnly, pvtOnly, publicOnly, active)
 
 	utils.Log.Debug("Fetching scope of each program. Concurrency: ", concurrency)
 	ids := make(chan string, concurrency)
@@ -222,14 +258,25 @@ func GetAllProgramsScope(authorization string, bbpOnly bool, pvtOnly bool, publi
 					break
 				}
 
-				programData, err := getProgramScope(authorization, id, bbpOnly, getCategories(categories), includeOOS)
+				programData, err := getProgramScope(authorization, id, bbpOnly, getCategories(categories), minSeverity, includeOOS)
 
 				if err != nil {
 					utils.Log.Warn("Error fetching program scope: ", err)
-					errors <- err
+					// Keep the first error only, never block the worker
+					select {
+					case errors <- err:
+					default:
+					}
 					continue
 				}
 
+				// offers_bounties is set per program, but its paid assets may all have been filtered out
+				if bbpOnly && len(programData.InScope) == 0 {
+					continue
+				}
+
+				programData.IsBBP = offersBounties[id]
+
 				mu.Lock()
 				programs = append(programs, programData)
 
@@ -252,10 +299,10 @@ func GetAllProgramsScope(authorization string, bbpOnly bool, pvtOnly bool, publi
 	processGroup.Wait()
 	close(errors) // Close the errors channel after all goroutines are done
 
-	// Check if there were any errors
+	// Check if there were any errors, returning partial results
 	for err := range errors {
 		if err != nil {
-			return nil, err
+			return programs, err
 		}
 	}
 
@@ -276,6 +323,7 @@ func HacktivityMonitor(pages int) {
 
 		if err != nil {
 			utils.Log.Warn("HTTP request failed: ", err)
+			continue
 		}
 
 		if res.StatusCode != 200 {
@@ -0,0 +1,206 @@
+package hackerone
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/sw33tLie/bbscope/internal/testutil"
+	"github.com/sw33tLie/bbscope/pkg/scope"
+)
+
+const testAuthorization = "dGVzdDp0b2tlbg==" // test:token
+
+// hackeroneHandler serves two pages of programs, and the structured scopes of each program from testdata
+func hackeroneHandler(t *testing.T) http.Handler {
+	fixtures := map[string]string{
+		"/v1/hackers/programs?page%5Bsize%5D=100":                                                  "programs_page1.json",
+		"/v1/hackers/programs?page%5Bnumber%5D=2&page%5Bsize%5D=100":                               "programs_page2.json",
+		"/v1/hackers/programs/acme/structured_scopes?page%5Bnumber%5D=1&page%5Bsize%5D=100":        "scopes_acme_page1.json",
+		"/v1/hackers/programs/acme/structured_scopes?page%5Bnumber%5D=2&page%5Bsize%5D=100":        "scopes_acme_page2.json",
+		"/v1/hackers/programs/publitas/structured_scopes?page%5Bnumber%5D=1&page%5Bsize%5D=100":    "scopes_publitas.json",
+		"/v1/hackers/programs/khanacademy/structured_scopes?page%5Bnumber%5D=1&page%5Bsize%5D=100": "scopes_khanacademy.json",
+		"/v1/hackers/programs/globex-vdp/structured_scopes?page%5Bnumber%5D=1&page%5Bsize%5D=100":  "scopes_globex-vdp.json",
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Basic "+testAuthorization {
+			t.Errorf("got Authorization header %q", r.Header.Get("Authorization"))
+		}
+
+		fixture, ok := fixtures[r.URL.RequestURI()]
+		if !ok {
+			t.Errorf("unexpected request %q", r.URL.RequestURI())
+			http.NotFound(w, r)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(testutil.ReadFixture(t, fixture))
+	})
+}
+
+// targets lists the targets of elements, for comparisons
+func targets(elements []scope.ScopeElement) string {
+	var list []string
+	for _, element := range elements {
+		list = append(list, element.Target)
+	}
+	return strings.Join(list, " ")
+}
+
+func TestGetProgramScopeWithoutScopeTable(t *testing.T) {
+	testutil.UseTestServer(t, hackeroneHandler(t))
+
+	tests := []struct {
+		bbpOnly bool
+		want    string
+	}{
+		{false, scope.NO_IN_SCOPE_TABLE},
+		{true, ""},
+	}
+
+	for _, test := range tests {
+		pData, err := getProgramScope(testAuthorization, "publitas", test.bbpOnly, nil, "none", true)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if got := targets(pData.InScope); got != test.want {
+			t.Errorf("bbpOnly %v: got in scope %q, want %q", test.bbpOnly, got, test.want)
+		}
+	}
+}
+
+func TestGetProgramScopeWithoutPaidAssets(t *testing.T) {
+	testutil.UseTestServer(t, hackeroneHandler(t))
+
+	tests := []struct {
+		bbpOnly    bool
+		inScope    string
+		outOfScope string
+	}{
+		{false, "www.khanacademy.org", "blog.khanacademy.org"},
+		// Out of scope assets are only listed alongside paid ones
+		{true, "", ""},
+	}
+
+	for _, test := range tests {
+		pData, err := getProgramScope(testAuthorization, "khanacademy", test.bbpOnly, nil, "none", true)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if got := targets(pData.InScope); got != test.inScope {
+			t.Errorf("bbpOnly %v: got in scope %q, want %q", test.bbpOnly, got, test.inScope)
+		}
+
+		if got := targets(pData.OutOfScope); got != test.outOfScope {
+			t.Errorf("bbpOnly %v: got out of scope %q, want %q", test.bbpOnly, got, test.outOfScope)
+		}
+	}
+}
+
+func TestGetAllProgramsScope(t *testing.T) {
+	testutil.UseTestServer(t, hackeroneHandler(t))
+
+	tests := []struct {
+		bbpOnly bool
+		want    []string
+	}{
+		{false, []string{
+			"https://hackerone.com/acme bbp in: *.acme.com status.acme.com com.acme.app out: blog.acme.com",
+			"https://hackerone.com/publitas bbp in: " + scope.NO_IN_SCOPE_TABLE + " out: ",
+			"https://hackerone.com/khanacademy bbp in: www.khanacademy.org out: blog.khanacademy.org",
+			"https://hackerone.com/globex-vdp vdp in: *.globex.com out: ",
+		}},
+		// Publitas has no scope table, Khan Academy no paid asset, and Globex doesn't offer bounties
+		{true, []string{
+			"https://hackerone.com/acme bbp in: *.acme.com com.acme.app out: blog.acme.com",
+		}},
+	}
+
+	for _, test := range tests {
+		programs, err := GetAllProgramsScope(testAuthorization, test.bbpOnly, false, false, "all", "", false, 1, false, "t", " ", true)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var got []string
+		for _, pData := range programs {
+			kind := "vdp"
+			if pData.IsBBP {
+				kind = "bbp"
+			}
+			got = append(got, fmt.Sprintf("%s %s in: %s out: %s", pData.Url, kind, targets(pData.InScope), targets(pData.OutOfScope)))
+		}
+
+		if strings.Join(got, "\n") != strings.Join(test.want, "\n") {
+			t.Errorf("bbpOnly %v: got programs\n%s\nwant\n%s", test.bbpOnly, strings.Join(got, "\n"), strings.Join(test.want, "\n"))
+		}
+
+		if test.bbpOnly {
+			continue
+		}
+
+		if description := programs[0].InScope[0].Description; description != "Everything under acme.com  except the blog" {
+			t.Errorf("got description %q, want newlines replaced", description)
+		}
+	}
+}
+
+// Asset types returned by HackerOne's structured scopes API
+var knownAssetTypes = []string{
+	"URL", "WILDCARD", "IP_ADDRESS", "CIDR",
+	"GOOGLE_PLAY_APP_ID", "OTHER_APK", "APPLE_STORE_APP_ID", "OTHER_IPA", "TESTFLIGHT",
+	"AI_MODEL", "OTHER", "HARDWARE", "SOURCE_CODE", "SMART_CONTRACT",
+	"DOWNLOADABLE_EXECUTABLES", "WINDOWS_APP_STORE_APP_ID",
+}
+
+// User-facing -c values, except "all" which disables filtering
+var categoryNames = []string{"url", "cidr", "mobile", "android", "apple", "ai", "other", "hardware", "code", "executable"}
+
+func TestEveryAssetTypeIsReachable(t *testing.T) {
+	testutil.CheckCategoriesReachable(t, knownAssetTypes, categoryNames, getCategories)
+
+	if getCategories("all") != nil {
+		t.Error("category all should disable filtering")
+	}
+}
+
+func FuzzGetProgramScope(f *testing.F) {
+	f.Add([]byte(`[{"attributes":{"asset_identifier":"*.example.com","asset_type":"WILDCARD","eligible_for_bounty":true,"eligible_for_submission":true,"max_severity":"critical"}}]`))
+	f.Add([]byte(`[{"attributes":{"asset_identifier":"example.com","asset_type":"URL","eligible_for_submission":false,"instruction":"Out\nof scope"}}]`))
+	f.Add([]byte(`[]`))
+
+	var mu sync.Mutex
+	var data []byte
+	var served bool
+
+	// Fuzz inputs are served as the data of the first page only, so that links.next can't loop forever
+	testutil.UseTestServer(f, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		if served {
+			w.Write([]byte(`{"data":[]}`))
+			return
+		}
+		served = true
+
+		w.Write([]byte(`{"data":`))
+		w.Write(data)
+		w.Write([]byte(`}`))
+	}))
+
+	f.Fuzz(func(t *testing.T, input []byte) {
+		mu.Lock()
+		data, served = input, false
+		mu.Unlock()
+
+		_, err := getProgramScope("token", "fuzz", false, nil, "none", true)
+		testutil.FailOnParseError(t, err)
+	})
+}
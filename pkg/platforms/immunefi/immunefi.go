@@ -3,30 +3,31 @@ package immunefi
 import (
 	"log"
 	"strings"
-	"sync"
 
-	"github.com/PuerkitoBio/goquery"
+	"github.com/sw33tLie/bbscope/internal/utils"
 	"github.com/sw33tLie/bbscope/pkg/scope"
 	"github.com/sw33tLie/bbscope/pkg/whttp"
 	"github.com/tidwall/gjson"
 )
 
 const (
-	PLATFORM_URL = "https://immunefi.com"
+	PLATFORM_URL      = "https://immunefi.com"
+	PROGRAMS_ENDPOINT = PLATFORM_URL + "/v2/bug-bounty/api-data"
 )
 
-func PrintAllScope(categories, outputFlags, delimiter string, concurrency int) {
-	programs := GetAllProgramsScope(categories, concurrency)
+func PrintAllScope(categories, outputFlags, delimiter string, includeOOS bool) {
+	programs := GetAllProgramsScope(categories, includeOOS)
 	for _, pData := range programs {
-		scope.PrintProgramScope(pData, outputFlags, delimiter, false)
+		scope.PrintProgramScope(pData, outputFlags, delimiter, includeOOS)
 	}
 }
 
 func getCategories(input string) []string {
 	categories := map[string][]string{
-		"web":       {"websites_and_applications"},
-		"contracts": {"smart_contract"},
-		"all":       {"websites_and_applications", "smart_contract"},
+		"web":        {"websites_and_applications"},
+		"contracts":  {"smart_contract"},
+		"blockchain": {"blockchain_dlt"},
+		"all":        {"websites_and_applications", "smart_contract", "blockchain_dlt"},
 	}
 
 	selectedCategory, ok := categories[strings.ToLower(input)]
@@ -36,14 +37,38 @@ func getCategories(input string) []string {
 	return selectedCategory
 }
 
-func GetAllProgramsScope(categories string, concurrency int) (programs []scope.ProgramData) {
+// Converts the assets of a scope block, keeping only the selected categories
+func getScopeElements(assets gjson.Result, selectedCategories []string) (elements []scope.ScopeElement) {
+	for _, asset := range assets.Array() {
+		target := asset.Get("url").Str
+		if target == "" {
+			target = asset.Get("target").Str
+		}
+		assetType := asset.Get("type").Str
+
+		for _, currentCat := range selectedCategories {
+			// Types look like "websites_and_applications" or "smart_contract", sometimes with a suffix
+			if strings.Contains(assetType, currentCat) {
+				elements = append(elements, scope.ScopeElement{
+					Target:      target,
+					Description: asset.Get("description").Str,
+					Category:    currentCat,
+				})
+				break
+			}
+		}
+	}
 
+	return elements
+}
+
+func GetAllProgramsScope(categories string, includeOOS bool) (programs []scope.ProgramData) {
 	res, err := whttp.SendHTTPRequest(
 		&whttp.WHTTPReq{
 			Method: "GET",
-			URL:    PLATFORM_URL + "/bug-bounty/",
+			URL:    PROGRAMS_ENDPOINT,
 			Headers: []whttp.WHTTPHeader{
-				{Name: "Accept", Value: "*/*"},
+				{Name: "Accept", Value: "application/json"},
 			},
 		}, nil)
 
@@ -51,105 +76,51 @@ func GetAllProgramsScope(categories string, concurrency int) (programs []scope.P
 		log.Fatal("HTTP request failed: ", err)
 	}
 
-	doc, err := goquery.NewDocumentFromReader(strings.NewReader(res.BodyString))
-
-	if err != nil {
-		log.Fatal("Failed to parse HTML")
+	if res.StatusCode != 200 {
+		log.Fatal("Fetching failed. Got status Code: ", res.StatusCode)
 	}
 
 	selectedCategories := getCategories(categories)
+	failedPrograms := 0
 
-	var programURLs []string
-	doc.Find("#__NEXT_DATA__").Each(func(index int, s *goquery.Selection) {
-		json := s.Contents().Text()
-		jsonPrograms := gjson.Get(json, "props.pageProps.bounties")
-
-		for _, program := range jsonPrograms.Array() {
-			programID := gjson.Get(program.Raw, "id")
-			isExternal := gjson.Get(program.Raw, "is_external").Bool()
+	// Every program embeds its scope, so a single request is enough
+	for _, program := range gjson.Parse(res.BodyString).Array() {
+		slug := program.Get("slug").Str
+		if slug == "" || program.Get("is_external").Bool() {
+			continue
+		}
 
-			if !isExternal {
-				programURLs = append(programURLs, PLATFORM_URL+"/bug-bounty/"+programID.Str+"/information/")
-			}
+		pData, err := getProgramData(slug, program, selectedCategories, includeOOS)
+		if err != nil {
+			// A single malformed program must not abort the whole run
+			utils.Log.Warn(err)
+			failedPrograms++
+			continue
 		}
-	})
-
-	// Iterate over all program pages
-	p := make(chan string, concurrency)
-	processGroup := new(sync.WaitGroup)
-	processGroup.Add(concurrency)
-
-	for i := 0; i < concurrency; i++ {
-		go func() {
-			for {
-				url := <-p
-
-				if url == "" {
-					break
-				}
-
-				res, err := whttp.SendHTTPRequest(
-					&whttp.WHTTPReq{
-						Method: "GET",
-						URL:    url,
-						Headers: []whttp.WHTTPHeader{
-							{Name: "Accept", Value: "*/*"},
-						},
-					}, nil)
-
-				if err != nil {
-					log.Fatal("HTTP request failed: ", err)
-				}
-
-				doc, err := goquery.NewDocumentFromReader(strings.NewReader(res.BodyString))
-
-				if err != nil {
-					log.Fatal("Failed to parse HTML")
-				}
-
-				doc.Find("#__NEXT_DATA__").Each(func(index int, s *goquery.Selection) {
-					json := s.Contents().Text()
-					jsonProgram := gjson.Get(json, "props.pageProps.bounty")
-					var tempScope []scope.ScopeElement
-
-					for _, scopeElement := range gjson.Get(jsonProgram.Raw, "assets").Array() {
-						elementTarget := gjson.Get(scopeElement.Raw, "url").Str
-						elementType := gjson.Get(scopeElement.Raw, "type").Str
-
-						for _, currentCat := range selectedCategories {
-							if currentCat == "websites_and_applications" && strings.Contains(elementType, "websites_and_applications") {
-								tempScope = append(tempScope, scope.ScopeElement{
-									Target:      elementTarget,
-									Description: "",
-									Category:    currentCat,
-								})
-							} else if currentCat == "smart_contract" && strings.Contains(elementType, "smart_contract") {
-								tempScope = append(tempScope, scope.ScopeElement{
-									Target:      elementTarget,
-									Description: "",
-									Category:    currentCat,
-								})
-							}
-						}
-					}
-
-					programs = append(programs, scope.ProgramData{
-						Url:        url,
-						InScope:    tempScope,
-						OutOfScope: nil,
-					})
-				})
 
-			}
-			processGroup.Done()
-		}()
+		programs = append(programs, pData)
 	}
 
-	for _, pURL := range programURLs {
-		p <- pURL
+	if failedPrograms > 0 {
+		utils.Log.Warn("Failed to parse the scope of ", failedPrograms, " programs")
 	}
 
-	close(p)
-	processGroup.Wait()
 	return programs
 }
+
+// Converts a program of the api-data listing
+func getProgramData(slug string, program gjson.Result, selectedCategories []string, includeOOS bool) (pData scope.ProgramData, err error) {
+	defer scope.RecoverParseError(slug, &err)
+
+	pData = scope.ProgramData{
+		Url:     PLATFORM_URL + "/bug-bounty/" + slug + "/",
+		IsBBP:   true, // Immunefi only lists bug bounty programs
+		InScope: getScopeElements(program.Get("scope.in_scope"), selectedCategories),
+	}
+
+	if includeOOS {
+		pData.OutOfScope = getScopeElements(program.Get("scope.out_of_scope"), selectedCategories)
+	}
+
+	return pData, nil
+}
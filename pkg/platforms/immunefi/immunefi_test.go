@@ -0,0 +1,112 @@
+package immunefi
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/sw33tLie/bbscope/internal/testutil"
+	"github.com/sw33tLie/bbscope/pkg/scope"
+	"github.com/tidwall/gjson"
+)
+
+// Asset types returned by Immunefi's api-data listing
+var knownAssetTypes = []string{"websites_and_applications", "smart_contract", "blockchain_dlt"}
+
+func TestEveryAssetTypeIsReachable(t *testing.T) {
+	testutil.CheckCategoriesReachable(t, knownAssetTypes, []string{"web", "contracts", "blockchain"}, getCategories)
+	testutil.CheckCategoriesReachable(t, knownAssetTypes, []string{"all"}, getCategories)
+}
+
+func FuzzGetProgramData(f *testing.F) {
+	f.Add([]byte(`[{"slug":"acme","scope":{"in_scope":[{"url":"https://acme.finance","type":"websites_and_applications"}],"out_of_scope":[{"target":"0x0","type":"smart_contract"}]}}]`))
+	f.Add([]byte(`[{"slug":"acme","scope":{"in_scope":{"url":1}}}]`))
+	f.Add([]byte(`{}`))
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		for _, program := range gjson.ParseBytes(data).Array() {
+			_, err := getProgramData("fuzz", program, getCategories("all"), true)
+			testutil.FailOnParseError(t, err)
+		}
+	})
+}
+
+// describe lists the target and category of each element, for comparisons
+func describe(elements []scope.ScopeElement) string {
+	var list []string
+	for _, element := range elements {
+		list = append(list, element.Target+" ("+element.Category+")")
+	}
+	return strings.Join(list, ", ")
+}
+
+func TestGetAllProgramsScope(t *testing.T) {
+	testutil.ServeFixture(t, http.StatusOK, "api_data.json")
+
+	// Initech is an external program, so it's never listed
+	tests := []struct {
+		categories string
+		includeOOS bool
+		want       []string
+	}{
+		{"all", false, []string{
+			"https://immunefi.com/bug-bounty/acmefinance/ in: https://etherscan.io/address/0x1111111111111111111111111111111111111111 (smart_contract), https://app.acme.finance (websites_and_applications), https://github.com/acme-finance/acme-chain (blockchain_dlt) out: ",
+			"https://immunefi.com/bug-bounty/globexdao/ in: https://arbiscan.io/address/0x2222222222222222222222222222222222222222 (smart_contract) out: ",
+		}},
+		{"all", true, []string{
+			"https://immunefi.com/bug-bounty/acmefinance/ in: https://etherscan.io/address/0x1111111111111111111111111111111111111111 (smart_contract), https://app.acme.finance (websites_and_applications), https://github.com/acme-finance/acme-chain (blockchain_dlt) out: https://blog.acme.finance (websites_and_applications)",
+			"https://immunefi.com/bug-bounty/globexdao/ in: https://arbiscan.io/address/0x2222222222222222222222222222222222222222 (smart_contract) out: ",
+		}},
+		{"web", true, []string{
+			"https://immunefi.com/bug-bounty/acmefinance/ in: https://app.acme.finance (websites_and_applications) out: https://blog.acme.finance (websites_and_applications)",
+			"https://immunefi.com/bug-bounty/globexdao/ in:  out: ",
+		}},
+		{"contracts", true, []string{
+			"https://immunefi.com/bug-bounty/acmefinance/ in: https://etherscan.io/address/0x1111111111111111111111111111111111111111 (smart_contract) out: ",
+			"https://immunefi.com/bug-bounty/globexdao/ in: https://arbiscan.io/address/0x2222222222222222222222222222222222222222 (smart_contract) out: ",
+		}},
+		{"blockchain", false, []string{
+			"https://immunefi.com/bug-bounty/acmefinance/ in: https://github.com/acme-finance/acme-chain (blockchain_dlt) out: ",
+			"https://immunefi.com/bug-bounty/globexdao/ in:  out: ",
+		}},
+	}
+
+	for _, test := range tests {
+		var got []string
+		for _, pData := range GetAllProgramsScope(test.categories, test.includeOOS) {
+			if !pData.IsBBP {
+				t.Errorf("%s is not marked as a bug bounty program", pData.Url)
+			}
+			got = append(got, fmt.Sprintf("%s in: %s out: %s", pData.Url, describe(pData.InScope), describe(pData.OutOfScope)))
+		}
+
+		if strings.Join(got, "\n") != strings.Join(test.want, "\n") {
+			t.Errorf("-c %s, includeOOS %v: got programs\n%s\nwant\n%s", test.categories, test.includeOOS, strings.Join(got, "\n"), strings.Join(test.want, "\n"))
+		}
+	}
+}
+
+func TestGetScopeElementsCategoryMapping(t *testing.T) {
+	assets := gjson.Parse(`[
+		{"type":"websites_and_applications","url":"https://acme.finance","description":"Web"},
+		{"type":"smart_contract","target":"0x1111111111111111111111111111111111111111","description":"Contract"},
+		{"type":"smart_contract_proxy","target":"0x3333333333333333333333333333333333333333"},
+		{"type":"blockchain_dlt","target":"https://github.com/acme-finance/acme-chain"},
+		{"type":"documentation","url":"https://docs.acme.finance"}
+	]`)
+
+	want := "https://acme.finance (websites_and_applications), " +
+		"0x1111111111111111111111111111111111111111 (smart_contract), " +
+		"0x3333333333333333333333333333333333333333 (smart_contract), " +
+		"https://github.com/acme-finance/acme-chain (blockchain_dlt)"
+
+	elements := getScopeElements(assets, getCategories("all"))
+	if got := describe(elements); got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+
+	if elements[0].Description != "Web" {
+		t.Errorf("got description %q, want %q", elements[0].Description, "Web")
+	}
+}
@@ -32,7 +32,9 @@ func GetCategoryID(input string) []int {
 	return selectedCategory
 }
 
-func GetProgramScope(token string, programID string, categories string, bbpOnly bool, includeOOS bool) (pData scope.ProgramData) {
+func GetProgramScope(token string, programID string, categories string, bbpOnly bool, includeOOS bool) (pData scope.ProgramData, err error) {
+	defer scope.RecoverParseError(programID, &err)
+
 	res, err := whttp.SendHTTPRequest(
 		&whttp.WHTTPReq{
 			Method: "GET",
@@ -65,10 +67,12 @@ func GetProgramScope(token string, programID string, categories string, bbpOnly
 		categoryID := value.Get("type.id").Int()
 		categoryValue := value.Get("type.value").Str
 		tierID := value.Get("tier.id").Int()
+		tierValue := value.Get("tier.value").Str
 		description := value.Get("description").Str
 
-		// Check if the tier ID is not 5 (out of scope)
+		// Tier IDs: 1 No Bounty, 2 Tier 3, 3 Tier 2, 4 Tier 1, 5 Out Of Scope
 		if tierID != 5 {
+			// Programs can pay for some tiers only, so unpaid assets are skipped individually
 			if !bbpOnly || (bbpOnly && tierID != 1) {
 				// Check if this element belongs to one of the categories the user chose
 				if isInArray(int(categoryID), GetCategoryID(categories)) {
@@ -76,6 +80,7 @@ func GetProgramScope(token string, programID string, categories string, bbpOnly
 						Target:      endpoint,
 						Description: strings.ReplaceAll(description, "\n", "  "),
 						Category:    categoryValue,
+						Tier:        tierValue,
 					})
 				}
 			}
@@ -93,13 +98,14 @@ func GetProgramScope(token string, programID string, categories string, bbpOnly
 		return true // Keep iterating
 	})
 
-	return pData
+	return pData, nil
 }
 
 func GetAllProgramsScope(token string, bbpOnly bool, pvtOnly bool, categories, outputFlags, delimiterCharacter string, includeOOS, printRealTime bool) (programs []scope.ProgramData) {
 	offset := 0
 	limit := 500
 	total := 0
+	failedPrograms := 0
 
 	for {
 		res, err := whttp.SendHTTPRequest(
@@ -129,17 +135,35 @@ func GetAllProgramsScope(token string, bbpOnly bool, pvtOnly bool, categories, o
 		records := gjson.Get(bodyString, "records").Array()
 		for _, record := range records {
 			id := record.Get("id").String()
-			maxBounty := record.Get("maxBounty.value").Int()
+			minBounty := record.Get("minBounty.value").Float()
+			maxBounty := record.Get("maxBounty.value").Float()
 			confidentialityLevel := record.Get("confidentialityLevel.id").Int()
-			programPath := strings.Split(record.Get("webLinks.detail").String(), "=")[1]
+
+			detailLink := strings.Split(record.Get("webLinks.detail").String(), "=")
+			if len(detailLink) < 2 {
+				utils.Log.Warn("Unexpected program link, skipping program ", id)
+				continue
+			}
+			programPath := detailLink[1]
 
 			// Types of confidentialityLevel: 1 InviteOnly, 2 Application, 3 Registered, 4 Public.
 			// We assume privates are 1, 2 and 3.
 
 			if (pvtOnly && confidentialityLevel != 4) || !pvtOnly {
 				if (bbpOnly && maxBounty != 0) || !bbpOnly {
-					pData := GetProgramScope(token, id, categories, bbpOnly, includeOOS)
+					pData, err := GetProgramScope(token, id, categories, bbpOnly, includeOOS)
+					if err != nil {
+						// A single malformed program must not abort the whole run
+						utils.Log.Warn(err)
+						failedPrograms++
+						continue
+					}
+
 					pData.Url = "https://app.intigriti.com/researcher" + programPath
+					pData.IsBBP = maxBounty != 0
+					pData.MinBounty = minBounty
+					pData.MaxBounty = maxBounty
+					pData.BountyCurrency = record.Get("maxBounty.currency").Str
 					if printRealTime {
 						scope.PrintProgramScope(pData, outputFlags, delimiterCharacter, includeOOS)
 					}
@@ -155,6 +179,10 @@ func GetAllProgramsScope(token string, bbpOnly bool, pvtOnly bool, categories, o
 		}
 	}
 
+	if failedPrograms > 0 {
+		utils.Log.Warn("Failed to parse the scope of ", failedPrograms, " programs")
+	}
+
 	return programs
 }
 
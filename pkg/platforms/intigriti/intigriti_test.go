@@ -0,0 +1,39 @@
+package intigriti
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/sw33tLie/bbscope/internal/testutil"
+)
+
+// Domain type IDs returned by Intigriti's researcher API
+var knownTypeIDs = []int{1, 2, 3, 4, 5, 6, 7}
+
+var categoryNames = []string{"url", "cidr", "mobile", "android", "apple", "device", "other", "wildcard"}
+
+func TestEveryTypeIDIsReachable(t *testing.T) {
+	testutil.CheckCategoriesReachable(t, knownTypeIDs, categoryNames, GetCategoryID)
+	testutil.CheckCategoriesReachable(t, knownTypeIDs, []string{"all"}, GetCategoryID)
+}
+
+func FuzzGetProgramScope(f *testing.F) {
+	f.Add(testutil.ReadFixture(f, "program.json"))
+	f.Add([]byte(`{"domains":{"content":[]}}`))
+	f.Add([]byte(`{"domains":{"content":[{"type":{"id":"1"},"tier":null}]}}`))
+
+	body := new(testutil.Body)
+	testutil.UseTestServer(f, body)
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		// Blocked requests are retried forever
+		if bytes.Contains(data, []byte("Request blocked")) {
+			t.Skip()
+		}
+
+		body.Set(data)
+
+		_, err := GetProgramScope("token", "fuzz", "all", false, true)
+		testutil.FailOnParseError(t, err)
+	})
+}
@@ -1,10 +1,15 @@
 package yeswehack
 
 import (
+	"encoding/base64"
+	"errors"
+	"fmt"
 	"log"
 	"strconv"
 	"strings"
+	"time"
 
+	"github.com/sw33tLie/bbscope/internal/utils"
 	"github.com/sw33tLie/bbscope/pkg/scope"
 	"github.com/sw33tLie/bbscope/pkg/whttp"
 	"github.com/tidwall/gjson"
@@ -15,6 +20,59 @@ const (
 	YESWEHACK_PROGRAM_BASE_ENDPOINT = "https://api.yeswehack.com/programs/"
 )
 
+// ErrTokenExpired is returned when the token is expired or rejected by the API
+var ErrTokenExpired = errors.New("YesWeHack token expired, re-authenticate")
+
+// ErrUnexpectedStatus is returned for any other non-200 response. It only concerns the requested resource,
+// e.g. a program removed between the listing and the scope request
+var ErrUnexpectedStatus = errors.New("unexpected status code")
+
+// checkTokenExpiry reads the exp claim of the JWT token, without verifying its signature.
+// Tokens that can't be decoded are left for the API to reject
+func checkTokenExpiry(token string) error {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
+	if err != nil {
+		return nil
+	}
+
+	exp := gjson.GetBytes(payload, "exp")
+	if exp.Exists() && time.Now().Unix() >= exp.Int() {
+		return ErrTokenExpired
+	}
+
+	return nil
+}
+
+func sendAPIRequest(token string, url string) (*whttp.WHTTPRes, error) {
+	res, err := whttp.SendHTTPRequest(
+		&whttp.WHTTPReq{
+			Method: "GET",
+			URL:    url,
+			Headers: []whttp.WHTTPHeader{
+				{Name: "Authorization", Value: "Bearer " + token},
+			},
+		}, nil)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if res.StatusCode == 401 {
+		return nil, ErrTokenExpired
+	}
+
+	if res.StatusCode != 200 {
+		return nil, fmt.Errorf("%w %d for %s", ErrUnexpectedStatus, res.StatusCode, url)
+	}
+
+	return res, nil
+}
+
 func GetCategoryID(input string) []string {
 	categories := map[string][]string{
 		"url":        {"web-application", "api", "ip-address"},
@@ -33,30 +91,38 @@ func GetCategoryID(input string) []string {
 	return selectedCategory
 }
 
-func GetProgramScope(token string, companySlug string, categories string) (pData scope.ProgramData) {
-	pData.Url = YESWEHACK_PROGRAM_BASE_ENDPOINT + companySlug
+func GetProgramScope(token string, companySlug string, categories string) (pData scope.ProgramData, err error) {
+	defer scope.RecoverParseError(companySlug, &err)
 
-	res, err := whttp.SendHTTPRequest(
-		&whttp.WHTTPReq{
-			Method: "GET",
-			URL:    pData.Url,
-			Headers: []whttp.WHTTPHeader{
-				{Name: "Authorization", Value: "Bearer " + token},
-			},
-		}, nil)
+	pData.Url = YESWEHACK_PROGRAM_BASE_ENDPOINT + companySlug
 
+	res, err := sendAPIRequest(token, pData.Url)
 	if err != nil {
-		log.Fatal("HTTP request failed: ", err)
+		return pData, err
+	}
+
+	selectedCatIDs := GetCategoryID(categories)
+
+	scopesResult := gjson.Get(res.BodyString, "scopes")
+	if !scopesResult.IsArray() {
+		return pData, fmt.Errorf("no scopes array in the response for %s", pData.Url)
 	}
+	scopes := scopesResult.Array()
 
-	chunkData := gjson.GetMany(res.BodyString, "scopes.#.scope", "scopes.#.scope_type")
+	// Same placeholder Bugcrowd and HackerOne use for programs without a scope table
+	if len(scopes) == 0 {
+		pData.InScope = append(pData.InScope, scope.ScopeElement{Target: scope.NO_IN_SCOPE_TABLE, Description: "", Category: ""})
+		return pData, nil
+	}
 
-	for i := 0; i < len(chunkData[0].Array()); i++ {
-		selectedCatIDs := GetCategoryID(categories)
+	// Read each scope as a whole, so that a missing field can't shift indexes
+	for _, scopeItem := range scopes {
+		target := scopeItem.Get("scope").Str
+		scopeType := scopeItem.Get("scope_type").Str
 
 		catMatches := false
 		for _, cat := range selectedCatIDs {
-			if cat == chunkData[1].Array()[i].Str {
+			if cat == scopeType {
 				catMatches = true
 				break
 			}
@@ -64,46 +130,48 @@ func GetProgramScope(token string, companySlug string, categories string) (pData
 
 		if catMatches {
 			pData.InScope = append(pData.InScope, scope.ScopeElement{
-				Target:      chunkData[0].Array()[i].Str,
+				Target:      target,
 				Description: "",
-				Category:    chunkData[1].Array()[i].Str,
+				Category:    scopeType,
 			})
 		}
 	}
 
-	return pData
+	return pData, nil
 }
 
-func GetAllProgramsScope(token string, bbpOnly bool, pvtOnly bool, categories string) (programs []scope.ProgramData) {
+// GetAllProgramsScope skips programs whose scope can't be fetched.
+// It only stops on listing errors and expired tokens, returning the programs fetched so far along with the error
+func GetAllProgramsScope(token string, bbpOnly bool, pvtOnly bool, categories string) (programs []scope.ProgramData, err error) {
+	if err := checkTokenExpiry(token); err != nil {
+		return nil, err
+	}
 
 	var page = 1
 	var nb_pages = 2
+	var failedPrograms int
 
 	for page <= nb_pages {
-		res, err := whttp.SendHTTPRequest(
-			&whttp.WHTTPReq{
-				Method: "GET",
-				URL:    YESWEHACK_PROGRAMS_ENDPOINT + "?page=" + strconv.Itoa(page),
-				Headers: []whttp.WHTTPHeader{
-					{Name: "Authorization", Value: "Bearer " + token},
-				},
-			}, nil)
-
+		res, err := sendAPIRequest(token, YESWEHACK_PROGRAMS_ENDPOINT+"?page="+strconv.Itoa(page))
 		if err != nil {
-			log.Fatal("HTTP request failed: ", err)
+			return programs, err
 		}
 
-		data := gjson.GetMany(res.BodyString, "items.#.slug", "items.#.bounty", "items.#.public")
-
-		allCompanySlugs := data[0].Array()
-		allRewarding := data[1].Array()
-
-		allPublic := data[2].Array()
-
-		for i := 0; i < len(allCompanySlugs); i++ {
-			if !pvtOnly || (pvtOnly && !allPublic[i].Bool()) {
-				if !bbpOnly || (bbpOnly && allRewarding[i].Bool()) {
-					pData := GetProgramScope(token, allCompanySlugs[i].Str, categories)
+		for _, item := range gjson.Get(res.BodyString, "items").Array() {
+			if !pvtOnly || (pvtOnly && !item.Get("public").Bool()) {
+				if !bbpOnly || (bbpOnly && item.Get("bounty").Bool()) {
+					pData, err := GetProgramScope(token, item.Get("slug").Str, categories)
+					if errors.Is(err, ErrTokenExpired) {
+						return programs, err
+					}
+
+					// A single unavailable program must not abort the whole run
+					if err != nil {
+						utils.Log.Warn("Error fetching program scope: ", err)
+						failedPrograms++
+						continue
+					}
+					pData.IsBBP = item.Get("bounty").Bool()
 					programs = append(programs, pData)
 				}
 			}
@@ -113,12 +181,17 @@ func GetAllProgramsScope(token string, bbpOnly bool, pvtOnly bool, categories st
 		page += 1
 	}
 
-	return programs
+	if failedPrograms > 0 {
+		utils.Log.Warn("Failed to fetch the scope of ", failedPrograms, " programs")
+	}
+
+	return programs, nil
 }
 
-func PrintAllScope(token string, bbpOnly bool, pvtOnly bool, categories string, outputFlags string, delimiter string) {
-	programs := GetAllProgramsScope(token, bbpOnly, pvtOnly, categories)
+func PrintAllScope(token string, bbpOnly bool, pvtOnly bool, categories string, outputFlags string, delimiter string) error {
+	programs, err := GetAllProgramsScope(token, bbpOnly, pvtOnly, categories)
 	for _, pData := range programs {
 		scope.PrintProgramScope(pData, outputFlags, delimiter, false)
 	}
+	return err
 }
@@ -0,0 +1,229 @@
+package yeswehack
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sw33tLie/bbscope/internal/testutil"
+	"github.com/sw33tLie/bbscope/pkg/scope"
+)
+
+// Scope types returned by YesWeHack's programs API
+var knownScopeTypes = []string{
+	"web-application", "api", "ip-address",
+	"mobile-application", "mobile-application-android", "mobile-application-ios",
+	"other", "application",
+}
+
+var categoryNames = []string{"url", "mobile", "android", "apple", "other", "executable"}
+
+func TestEveryScopeTypeIsReachable(t *testing.T) {
+	testutil.CheckCategoriesReachable(t, knownScopeTypes, categoryNames, GetCategoryID)
+	testutil.CheckCategoriesReachable(t, knownScopeTypes, []string{"all"}, GetCategoryID)
+}
+
+func TestGetProgramScopeEmptyScopes(t *testing.T) {
+	testutil.ServeFixture(t, http.StatusOK, "program_empty_scopes.json")
+
+	pData, err := GetProgramScope("token", "empty-program", "all")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(pData.InScope) != 1 || pData.InScope[0].Target != scope.NO_IN_SCOPE_TABLE {
+		t.Errorf("got in-scope %v, want a single NO_IN_SCOPE_TABLE element", pData.InScope)
+	}
+}
+
+func TestGetProgramScope(t *testing.T) {
+	testutil.ServeFixture(t, http.StatusOK, "program_scopes.json")
+
+	pData, err := GetProgramScope("token", "acme", "url")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"*.acme.com", "https://api.acme.com"}
+	if len(pData.InScope) != len(want) {
+		t.Fatalf("got in-scope %v, want targets %v", pData.InScope, want)
+	}
+	for i, target := range want {
+		if pData.InScope[i].Target != target {
+			t.Errorf("in-scope target %d = %q, want %q", i, pData.InScope[i].Target, target)
+		}
+	}
+
+	if pData.Url != YESWEHACK_PROGRAM_BASE_ENDPOINT+"acme" {
+		t.Errorf("got URL %q", pData.Url)
+	}
+}
+
+func TestGetProgramScopeErrorResponses(t *testing.T) {
+	tests := []struct {
+		name             string
+		statusCode       int
+		fixture          string
+		unexpectedStatus bool
+	}{
+		{"not found", http.StatusNotFound, "program_missing_scopes.json", true},
+		{"forbidden", http.StatusForbidden, "program_missing_scopes.json", true},
+		{"no scopes key", http.StatusOK, "program_missing_scopes.json", false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			testutil.ServeFixture(t, test.statusCode, test.fixture)
+
+			pData, err := GetProgramScope("token", "missing", "all")
+			if err == nil {
+				t.Fatalf("expected an error, got in-scope %v", pData.InScope)
+			}
+			if errors.Is(err, ErrTokenExpired) {
+				t.Errorf("got %v, only 401 responses mean the token expired", err)
+			}
+			if errors.Is(err, ErrUnexpectedStatus) != test.unexpectedStatus {
+				t.Errorf("got %v, want ErrUnexpectedStatus: %v", err, test.unexpectedStatus)
+			}
+			if len(pData.InScope) != 0 {
+				t.Errorf("no placeholder expected on errors, got %v", pData.InScope)
+			}
+		})
+	}
+}
+
+// testJWT builds an unsigned token holding the given claims
+func testJWT(claims string) string {
+	encode := base64.RawURLEncoding.EncodeToString
+	return encode([]byte(`{"alg":"RS256","typ":"JWT"}`)) + "." + encode([]byte(claims)) + ".signature"
+}
+
+// testPaddedJWT builds an unsigned token whose payload keeps its base64 padding
+func testPaddedJWT(claims string) string {
+	parts := strings.Split(testJWT(claims), ".")
+	parts[1] = base64.URLEncoding.EncodeToString([]byte(claims))
+	return strings.Join(parts, ".")
+}
+
+func TestCheckTokenExpiry(t *testing.T) {
+	now := time.Now().Unix()
+
+	tests := []struct {
+		name    string
+		token   string
+		expired bool
+	}{
+		{"expired", testJWT(fmt.Sprintf(`{"exp":%d}`, now-60)), true},
+		{"expiring now", testJWT(fmt.Sprintf(`{"exp":%d}`, now)), true},
+		{"valid", testJWT(fmt.Sprintf(`{"exp":%d}`, now+3600)), false},
+		{"no exp claim", testJWT(`{"sub":"hunter"}`), false},
+		{"padded payload", testPaddedJWT(fmt.Sprintf(`{"exp":%d, "sub":"hunter"}`, now-60)), true},
+		{"not a JWT", "opaque-token", false},
+		{"undecodable payload", "a.!!!.c", false},
+		{"empty", "", false},
+	}
+
+	if payload := strings.Split(tests[4].token, ".")[1]; !strings.HasSuffix(payload, "=") {
+		t.Fatalf("padded payload test case has no padding: %s", payload)
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := checkTokenExpiry(test.token)
+			if test.expired && !errors.Is(err, ErrTokenExpired) {
+				t.Errorf("got %v, want ErrTokenExpired", err)
+			}
+			if !test.expired && err != nil {
+				t.Errorf("got %v, want no error", err)
+			}
+		})
+	}
+}
+
+func TestExpiredTokenResponse(t *testing.T) {
+	testutil.ServeFixture(t, http.StatusUnauthorized, "expired_token.json")
+
+	if _, err := GetProgramScope("token", "acme", "all"); !errors.Is(err, ErrTokenExpired) {
+		t.Errorf("GetProgramScope: got %v, want ErrTokenExpired", err)
+	}
+
+	if _, err := GetAllProgramsScope("token", false, false, "all"); !errors.Is(err, ErrTokenExpired) {
+		t.Errorf("GetAllProgramsScope: got %v, want ErrTokenExpired", err)
+	}
+}
+
+func TestExpiredTokenSkipsRequests(t *testing.T) {
+	testutil.UseTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected request to %s", r.URL)
+	}))
+
+	programs, err := GetAllProgramsScope(testJWT(`{"exp":1}`), false, false, "all")
+	if !errors.Is(err, ErrTokenExpired) || programs != nil {
+		t.Errorf("got %v, %v, want no programs and ErrTokenExpired", programs, err)
+	}
+}
+
+// programsHandler serves the programs listing and the acme program. Other programs get removedStatus
+func programsHandler(t *testing.T, removedStatus int) http.Handler {
+	listing := testutil.ReadFixture(t, "programs_page.json")
+	acme := testutil.ReadFixture(t, "program_scopes.json")
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		switch r.URL.Path {
+		case "/programs":
+			w.Write(listing)
+		case "/programs/acme":
+			w.Write(acme)
+		default:
+			w.WriteHeader(removedStatus)
+			w.Write([]byte(`{"code":404,"message":"Not Found"}`))
+		}
+	})
+}
+
+func TestGetAllProgramsScopeSkipsFailedPrograms(t *testing.T) {
+	testutil.UseTestServer(t, programsHandler(t, http.StatusNotFound))
+
+	programs, err := GetAllProgramsScope("token", false, false, "url")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(programs) != 1 || programs[0].Url != YESWEHACK_PROGRAM_BASE_ENDPOINT+"acme" {
+		t.Fatalf("got programs %v, want only acme", programs)
+	}
+
+	if !programs[0].IsBBP {
+		t.Error("acme should be a bug bounty program")
+	}
+}
+
+func TestGetAllProgramsScopeStopsOnExpiredToken(t *testing.T) {
+	testutil.UseTestServer(t, programsHandler(t, http.StatusUnauthorized))
+
+	if _, err := GetAllProgramsScope("token", false, false, "url"); !errors.Is(err, ErrTokenExpired) {
+		t.Fatalf("got %v, want ErrTokenExpired", err)
+	}
+}
+
+func FuzzGetProgramScope(f *testing.F) {
+	for _, fixture := range []string{"program_scopes.json", "program_empty_scopes.json", "program_missing_scopes.json"} {
+		f.Add(testutil.ReadFixture(f, fixture))
+	}
+
+	body := new(testutil.Body)
+	testutil.UseTestServer(f, body)
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		body.Set(data)
+
+		_, err := GetProgramScope("token", "fuzz", "all")
+		testutil.FailOnParseError(t, err)
+	})
+}
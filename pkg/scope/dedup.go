@@ -0,0 +1,105 @@
+package scope
+
+import (
+	"sort"
+	"strings"
+)
+
+type DedupStrategy string
+
+const (
+	// DedupByNormalized keeps one in-scope element per normalized target, across all programs
+	DedupByNormalized DedupStrategy = "normalized"
+	// DedupByWildcard also drops targets already covered by an in-scope wildcard
+	DedupByWildcard DedupStrategy = "wildcard"
+)
+
+// NormalizeTarget lowercases a target and strips its URL scheme, trailing slashes and trailing dot
+func NormalizeTarget(target string) string {
+	normalized := strings.ToLower(strings.TrimSpace(target))
+	if i := strings.Index(normalized, "://"); i != -1 {
+		normalized = normalized[i+3:]
+	}
+	normalized = strings.TrimRight(normalized, "/")
+	return strings.TrimSuffix(normalized, ".")
+}
+
+// getHost returns the host part of a normalized target
+func getHost(normalized string) string {
+	if i := strings.IndexAny(normalized, "/?#"); i != -1 {
+		normalized = normalized[:i]
+	}
+	if i := strings.LastIndex(normalized, ":"); i != -1 && !strings.Contains(normalized[:i], ":") {
+		normalized = normalized[:i]
+	}
+	return strings.TrimSuffix(normalized, ".")
+}
+
+// isCoveredByWildcard reports whether host is a subdomain of one of the wildcard domains.
+// Wildcards don't cover their own apex domain
+func isCoveredByWildcard(host string, wildcardDomains map[string]bool) bool {
+	for i := 0; i < len(host); i++ {
+		if host[i] == '.' && wildcardDomains[host[i+1:]] {
+			return true
+		}
+	}
+	return false
+}
+
+// Dedup removes duplicate in-scope elements across programs, keeping the first occurrence.
+// Programs are returned sorted by URL, so that the same targets are kept whatever order the programs were fetched in.
+// Out-of-scope elements are left untouched
+func Dedup(programs []ProgramData, strategy DedupStrategy) []ProgramData {
+	sorted := make([]ProgramData, len(programs))
+	copy(sorted, programs)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Url < sorted[j].Url
+	})
+	programs = sorted
+
+	wildcardDomains := make(map[string]bool)
+	if strategy == DedupByWildcard {
+		for _, pData := range programs {
+			for _, scopeElement := range pData.InScope {
+				if normalized := NormalizeTarget(scopeElement.Target); strings.HasPrefix(normalized, "*.") {
+					wildcardDomains[getHost(normalized[2:])] = true
+				}
+			}
+		}
+	}
+
+	seen := make(map[string]bool)
+	deduped := make([]ProgramData, 0, len(programs))
+
+	for _, pData := range programs {
+		inScope := []ScopeElement{}
+
+		for _, scopeElement := range pData.InScope {
+			// Placeholders aren't actual targets, so they are never duplicates
+			if IsPlaceholder(scopeElement.Target) {
+				inScope = append(inScope, scopeElement)
+				continue
+			}
+
+			normalized := NormalizeTarget(scopeElement.Target)
+			if seen[normalized] {
+				continue
+			}
+			seen[normalized] = true
+
+			if strategy == DedupByWildcard {
+				// "*.api.example.com" is covered by "*.example.com" too
+				if isCoveredByWildcard(getHost(strings.TrimPrefix(normalized, "*.")), wildcardDomains) {
+					continue
+				}
+			}
+
+			inScope = append(inScope, scopeElement)
+		}
+
+		pData.InScope = inScope
+		deduped = append(deduped, pData)
+	}
+
+	return deduped
+}
@@ -0,0 +1,59 @@
+package scope
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// describePrograms lists the URL and in-scope targets of each program, for comparisons
+func describePrograms(programs []ProgramData) string {
+	var lines []string
+	for _, pData := range programs {
+		var targets []string
+		for _, scopeElement := range pData.InScope {
+			targets = append(targets, scopeElement.Target)
+		}
+		lines = append(lines, fmt.Sprintf("%s: %s", pData.Url, strings.Join(targets, " ")))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func TestDedupIsDeterministic(t *testing.T) {
+	acme := ProgramData{Url: "https://hackerone.com/acme", InScope: []ScopeElement{{Target: "https://API.example.com/"}, {Target: "acme.com"}}}
+	globex := ProgramData{Url: "https://hackerone.com/globex", InScope: []ScopeElement{{Target: "api.example.com"}, {Target: "*.example.com"}}}
+	initech := ProgramData{Url: "https://hackerone.com/initech", InScope: []ScopeElement{{Target: "www.example.com"}, {Target: NO_IN_SCOPE_TABLE}}}
+
+	tests := []struct {
+		strategy DedupStrategy
+		want     string
+	}{
+		{DedupByNormalized, "https://hackerone.com/acme: https://API.example.com/ acme.com\n" +
+			"https://hackerone.com/globex: *.example.com\n" +
+			"https://hackerone.com/initech: www.example.com " + NO_IN_SCOPE_TABLE},
+		{DedupByWildcard, "https://hackerone.com/acme: acme.com\n" +
+			"https://hackerone.com/globex: *.example.com\n" +
+			"https://hackerone.com/initech: " + NO_IN_SCOPE_TABLE},
+	}
+
+	// Workers return programs in any order
+	orders := [][]ProgramData{
+		{acme, globex, initech},
+		{initech, globex, acme},
+		{globex, acme, initech},
+	}
+
+	for _, test := range tests {
+		for _, programs := range orders {
+			if got := describePrograms(Dedup(programs, test.strategy)); got != test.want {
+				t.Errorf("%s dedup with %s first:\ngot\n%s\nwant\n%s", test.strategy, programs[0].Url, got, test.want)
+			}
+		}
+	}
+
+	programs := []ProgramData{initech, acme}
+	Dedup(programs, DedupByNormalized)
+	if programs[0].Url != initech.Url {
+		t.Error("Dedup reordered the caller's slice")
+	}
+}
@@ -0,0 +1,147 @@
+package scope
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"strconv"
+	"strings"
+)
+
+func PrintProgramScope(programScope ProgramData, outputFlags string, delimiter string, includeOOS bool) {
+	printScope := func(scope []ScopeElement, prefix string) {
+		for _, scopeElement := range scope {
+			line := createLine(scopeElement, programScope, outputFlags, delimiter)
+			if len(line) > 0 {
+				fmt.Println(prefix + line)
+			}
+		}
+	}
+
+	printScope(programScope.InScope, "")
+	if includeOOS {
+		printScope(programScope.OutOfScope, "[OOS] ")
+	}
+}
+
+func createLine(scopeElement ScopeElement, programScope ProgramData, outputFlags, delimiter string) string {
+	var line string
+	for _, f := range outputFlags {
+		switch f {
+		case 't':
+			line += scopeElement.Target + delimiter
+		case 'd':
+			line += scopeElement.Description + delimiter
+		case 'c':
+			line += scopeElement.Category + delimiter
+		case 'u':
+			line += programScope.Url + delimiter
+		case 's':
+			line += scopeElement.MaxSeverity + delimiter
+		case 'm':
+			line += programScope.BountyRange() + delimiter
+		case 'r':
+			line += scopeElement.Tier + delimiter
+		default:
+			log.Fatal("Invalid print flag")
+		}
+	}
+	return strings.TrimSuffix(line, delimiter)
+}
+
+// ScopeRecord is a scope element along with its program data, as printed by the json and csv formats
+type ScopeRecord struct {
+	ProgramURL  string `json:"program_url"`
+	Platform    string `json:"platform"`
+	Target      string `json:"target"`
+	Category    string `json:"category"`
+	Description string `json:"description"`
+	MaxSeverity string `json:"max_severity,omitempty"`
+	Tier        string `json:"tier,omitempty"`
+	BountyRange string `json:"bounty_range,omitempty"`
+	InScope     bool   `json:"in_scope"`
+	IsBBP       bool   `json:"is_bbp"`
+}
+
+func getScopeRecords(programScope ProgramData, platform string, includeOOS bool) (records []ScopeRecord) {
+	addRecords := func(scope []ScopeElement, inScope bool) {
+		for _, scopeElement := range scope {
+			records = append(records, ScopeRecord{
+				ProgramURL:  programScope.Url,
+				Platform:    platform,
+				Target:      scopeElement.Target,
+				Category:    scopeElement.Category,
+				Description: scopeElement.Description,
+				MaxSeverity: scopeElement.MaxSeverity,
+				Tier:        scopeElement.Tier,
+				BountyRange: programScope.BountyRange(),
+				InScope:     inScope,
+				IsBBP:       programScope.IsBBP,
+			})
+		}
+	}
+
+	addRecords(programScope.InScope, true)
+	if includeOOS {
+		addRecords(programScope.OutOfScope, false)
+	}
+
+	return records
+}
+
+// PrintProgramScopeJSON prints one JSON object per scope element (JSON lines)
+func PrintProgramScopeJSON(programScope ProgramData, platform string, includeOOS bool) {
+	for _, record := range getScopeRecords(programScope, platform, includeOOS) {
+		line, err := json.Marshal(record)
+		if err != nil {
+			log.Fatal("Failed to encode scope element: ", err)
+		}
+		fmt.Println(string(line))
+	}
+}
+
+// WriteJSON writes a single JSON array holding the scope elements of all programs
+func WriteJSON(w io.Writer, programs []ProgramData, platform string, includeOOS bool) error {
+	records := []ScopeRecord{}
+	for _, programScope := range programs {
+		records = append(records, getScopeRecords(programScope, platform, includeOOS)...)
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(records)
+}
+
+// WriteCSV writes a header row followed by one row per scope element
+func WriteCSV(w io.Writer, programs []ProgramData, platform string, includeOOS bool) error {
+	csvWriter := csv.NewWriter(w)
+
+	if err := csvWriter.Write([]string{"program_url", "platform", "target", "category", "description", "max_severity", "tier", "bounty_range", "in_scope", "is_bbp"}); err != nil {
+		return err
+	}
+
+	for _, programScope := range programs {
+		for _, record := range getScopeRecords(programScope, platform, includeOOS) {
+			err := csvWriter.Write([]string{
+				record.ProgramURL,
+				record.Platform,
+				record.Target,
+				record.Category,
+				record.Description,
+				record.MaxSeverity,
+				record.Tier,
+				record.BountyRange,
+				strconv.FormatBool(record.InScope),
+				strconv.FormatBool(record.IsBBP),
+			})
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	csvWriter.Flush()
+	return csvWriter.Error()
+}
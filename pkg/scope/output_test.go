@@ -0,0 +1,77 @@
+package scope
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+var intigritiProgram = ProgramData{
+	Url:            "https://app.intigriti.com/researcher/programs/acme/acme/detail",
+	IsBBP:          true,
+	MinBounty:      50,
+	MaxBounty:      2500.5,
+	BountyCurrency: "EUR",
+	InScope:        []ScopeElement{{Target: "*.acme.com", Category: "Wildcard", Tier: "Tier 1"}},
+}
+
+func TestBountyRange(t *testing.T) {
+	tests := []struct {
+		program ProgramData
+		want    string
+	}{
+		{intigritiProgram, "50-2500.5 EUR"},
+		{ProgramData{MaxBounty: 1000}, "0-1000"},
+		{ProgramData{}, ""},
+	}
+
+	for _, test := range tests {
+		if got := test.program.BountyRange(); got != test.want {
+			t.Errorf("got %q, want %q", got, test.want)
+		}
+	}
+}
+
+func TestCreateLineTierAndBountyRange(t *testing.T) {
+	got := createLine(intigritiProgram.InScope[0], intigritiProgram, "trm", " ")
+	if want := "*.acme.com Tier 1 50-2500.5 EUR"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestWriteJSONTierAndBountyRange(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteJSON(&buf, []ProgramData{intigritiProgram}, "intigriti", false); err != nil {
+		t.Fatal(err)
+	}
+
+	var records []ScopeRecord
+	if err := json.Unmarshal(buf.Bytes(), &records); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(records) != 1 || records[0].Tier != "Tier 1" || records[0].BountyRange != "50-2500.5 EUR" {
+		t.Errorf("got records %+v", records)
+	}
+}
+
+func TestWriteCSVTierAndBountyRange(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteCSV(&buf, []ProgramData{intigritiProgram}, "intigriti", false); err != nil {
+		t.Fatal(err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("got %d lines, want a header and one row", len(lines))
+	}
+
+	if want := "program_url,platform,target,category,description,max_severity,tier,bounty_range,in_scope,is_bbp"; lines[0] != want {
+		t.Errorf("got header %q, want %q", lines[0], want)
+	}
+
+	if want := intigritiProgram.Url + ",intigriti,*.acme.com,Wildcard,,,Tier 1,50-2500.5 EUR,true,true"; lines[1] != want {
+		t.Errorf("got row %q, want %q", lines[1], want)
+	}
+}
@@ -2,53 +2,66 @@ package scope
 
 import (
 	"fmt"
-	"log"
-	"strings"
+	"strconv"
 )
 
+// Targets used in place of actual ones, e.g. for programs without a scope table
+const (
+	NO_IN_SCOPE_TABLE = "NO_IN_SCOPE_TABLE"
+	TWO_FA_REQUIRED   = "2FA_REQUIRED"
+)
+
+// IsPlaceholder reports whether target is empty or a placeholder rather than an actual target
+func IsPlaceholder(target string) bool {
+	return target == "" || target == NO_IN_SCOPE_TABLE || target == TWO_FA_REQUIRED
+}
+
 type ScopeElement struct {
 	Target      string
 	Description string
 	Category    string
+	MaxSeverity string // HackerOne only
+	Tier        string // Intigriti only
 }
 
 type ProgramData struct {
-	Url        string
-	InScope    []ScopeElement
-	OutOfScope []ScopeElement
+	Url            string
+	IsBBP          bool
+	MinBounty      float64 // Intigriti only
+	MaxBounty      float64 // Intigriti only
+	BountyCurrency string  // Intigriti only
+	InScope        []ScopeElement
+	OutOfScope     []ScopeElement
 }
 
-func PrintProgramScope(programScope ProgramData, outputFlags string, delimiter string, includeOOS bool) {
-	printScope := func(scope []ScopeElement, prefix string) {
-		for _, scopeElement := range scope {
-			line := createLine(scopeElement, programScope.Url, outputFlags, delimiter)
-			if len(line) > 0 {
-				fmt.Println(prefix + line)
-			}
-		}
+// BountyRange returns the program's bounty range (e.g. "50-5000 EUR"), or an empty string if unknown
+func (p ProgramData) BountyRange() string {
+	if p.MinBounty == 0 && p.MaxBounty == 0 {
+		return ""
 	}
 
-	printScope(programScope.InScope, "")
-	if includeOOS {
-		printScope(programScope.OutOfScope, "[OOS] ")
+	bountyRange := strconv.FormatFloat(p.MinBounty, 'f', -1, 64) + "-" + strconv.FormatFloat(p.MaxBounty, 'f', -1, 64)
+	if p.BountyCurrency != "" {
+		bountyRange += " " + p.BountyCurrency
 	}
+
+	return bountyRange
+}
+
+// ParseError is returned instead of crashing when a program's scope can't be parsed
+type ParseError struct {
+	Handle string
+	Reason string
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("failed to parse scope of %s: %s", e.Handle, e.Reason)
 }
 
-func createLine(scopeElement ScopeElement, url, outputFlags, delimiter string) string {
-	var line string
-	for _, f := range outputFlags {
-		switch f {
-		case 't':
-			line += scopeElement.Target + delimiter
-		case 'd':
-			line += scopeElement.Description + delimiter
-		case 'c':
-			line += scopeElement.Category + delimiter
-		case 'u':
-			line += url + delimiter
-		default:
-			log.Fatal("Invalid print flag")
-		}
+// RecoverParseError must be deferred by functions parsing a program's scope.
+// It turns a panic (e.g. an unexpected API response) into a *ParseError stored in err
+func RecoverParseError(handle string, err *error) {
+	if r := recover(); r != nil {
+		*err = &ParseError{Handle: handle, Reason: fmt.Sprint(r)}
 	}
-	return strings.TrimSuffix(line, delimiter)
 }
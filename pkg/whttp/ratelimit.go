@@ -0,0 +1,180 @@
+package whttp
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/go-retryablehttp"
+)
+
+// tokenBucket allows up to burst requests at once, refilled at rate tokens per second
+type tokenBucket struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+var (
+	rateLimitersMu sync.RWMutex
+	rateLimiters   = map[string]*tokenBucket{}
+)
+
+func init() {
+	// Bugcrowd bans clients sending more than about one request per second
+	SetRateLimit("bugcrowd.com", 1, 1)
+}
+
+func newTokenBucket(rate float64, burst int) *tokenBucket {
+	return &tokenBucket{
+		rate:   rate,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   time.Now(),
+	}
+}
+
+// wait blocks until a token is available. Tokens are reserved under the lock,
+// so concurrent callers sleep in parallel without serializing other hosts
+func (b *tokenBucket) wait() {
+	b.mu.Lock()
+
+	now := time.Now()
+	b.tokens = math.Min(b.burst, b.tokens+now.Sub(b.last).Seconds()*b.rate)
+	b.last = now
+	b.tokens--
+
+	var delay time.Duration
+	if b.tokens < 0 {
+		delay = time.Duration(-b.tokens / b.rate * float64(time.Second))
+	}
+
+	b.mu.Unlock()
+
+	time.Sleep(delay)
+}
+
+// SetRateLimit limits requests to host and its subdomains to rate requests per second.
+// A rate of 0 or less removes the limit
+func SetRateLimit(host string, rate float64, burst int) {
+	host = strings.ToLower(strings.TrimPrefix(host, "."))
+
+	rateLimitersMu.Lock()
+	defer rateLimitersMu.Unlock()
+
+	if rate <= 0 {
+		delete(rateLimiters, host)
+		return
+	}
+
+	if burst < 1 {
+		burst = 1
+	}
+
+	rateLimiters[host] = newTokenBucket(rate, burst)
+}
+
+// SetRateLimits parses and applies a comma separated list of limits.
+// Example: "bugcrowd.com=1/s,hackerone.com=5/s:10", where the optional number after ':' is the burst size
+func SetRateLimits(spec string) error {
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		parts := strings.SplitN(entry, "=", 2)
+		if len(parts) != 2 || parts[0] == "" {
+			return fmt.Errorf("invalid rate limit %q, expected host=N/s", entry)
+		}
+
+		rate, burst, err := parseRate(parts[1])
+		if err != nil {
+			return fmt.Errorf("invalid rate limit %q: %v", entry, err)
+		}
+
+		SetRateLimit(parts[0], rate, burst)
+	}
+
+	return nil
+}
+
+// parseRate parses "N/s", "N/m" or "N/h", optionally followed by ":burst"
+func parseRate(value string) (rate float64, burst int, err error) {
+	if i := strings.Index(value, ":"); i != -1 {
+		burst, err = strconv.Atoi(value[i+1:])
+		if err != nil || burst < 1 {
+			return 0, 0, fmt.Errorf("invalid burst %q", value[i+1:])
+		}
+		value = value[:i]
+	}
+
+	parts := strings.SplitN(value, "/", 2)
+	if len(parts) != 2 {
+		return 0, 0, fmt.Errorf("missing time unit in %q", value)
+	}
+
+	rate, err = strconv.ParseFloat(parts[0], 64)
+	if err != nil || rate < 0 {
+		return 0, 0, fmt.Errorf("invalid rate %q", parts[0])
+	}
+
+	switch parts[1] {
+	case "s":
+	case "m":
+		rate /= 60
+	case "h":
+		rate /= 3600
+	default:
+		return 0, 0, fmt.Errorf("invalid time unit %q (available: s, m, h)", parts[1])
+	}
+
+	if burst == 0 {
+		burst = int(math.Max(1, math.Ceil(rate)))
+	}
+
+	return rate, burst, nil
+}
+
+// getRateLimiter returns the limiter of the most specific configured host matching host, if any
+func getRateLimiter(host string) *tokenBucket {
+	host = strings.ToLower(host)
+
+	rateLimitersMu.RLock()
+	defer rateLimitersMu.RUnlock()
+
+	var bucket *tokenBucket
+	matchLength := 0
+	for limitedHost, currentBucket := range rateLimiters {
+		if (host == limitedHost || strings.HasSuffix(host, "."+limitedHost)) && len(limitedHost) > matchLength {
+			bucket = currentBucket
+			matchLength = len(limitedHost)
+		}
+	}
+
+	return bucket
+}
+
+// waitForHost blocks until a request to host is allowed by its rate limit
+func waitForHost(host string) {
+	if bucket := getRateLimiter(host); bucket != nil {
+		bucket.wait()
+	}
+}
+
+// LimitRequests makes client wait for the rate limit of the target host before every attempt, retries included
+func LimitRequests(client *retryablehttp.Client) {
+	hook := client.RequestLogHook
+	client.RequestLogHook = func(logger retryablehttp.Logger, req *http.Request, attempt int) {
+		waitForHost(req.URL.Hostname())
+		if hook != nil {
+			hook(logger, req, attempt)
+		}
+	}
+}
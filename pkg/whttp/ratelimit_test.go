@@ -0,0 +1,203 @@
+package whttp
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/go-retryablehttp"
+)
+
+// setTestRateLimit sets a rate limit removed at the end of the test
+func setTestRateLimit(t *testing.T, host string, rate float64, burst int) {
+	SetRateLimit(host, rate, burst)
+	t.Cleanup(func() {
+		SetRateLimit(host, 0, 0)
+	})
+}
+
+func TestTokenBucketBurst(t *testing.T) {
+	bucket := newTokenBucket(10, 3)
+
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		bucket.wait()
+	}
+	if elapsed := time.Since(start); elapsed > 50*time.Millisecond {
+		t.Fatalf("burst of 3 took %v, want no wait", elapsed)
+	}
+
+	start = time.Now()
+	bucket.wait()
+	if elapsed := time.Since(start); elapsed < 80*time.Millisecond {
+		t.Fatalf("request after the burst took %v, want about 100ms", elapsed)
+	}
+}
+
+func TestGetRateLimiterMatching(t *testing.T) {
+	setTestRateLimit(t, "example.test", 1, 1)
+	setTestRateLimit(t, "api.example.test", 5, 1)
+
+	parent := getRateLimiter("example.test")
+	if parent == nil {
+		t.Fatal("no limiter for example.test")
+	}
+
+	tests := []struct {
+		host string
+		want *tokenBucket
+	}{
+		{"example.test", parent},
+		{"EXAMPLE.test", parent},
+		{"www.example.test", parent},
+		{"a.b.example.test", parent},
+		{"notexample.test", nil},
+		{"example.test.evil", nil},
+		{"v2.api.example.test", getRateLimiter("api.example.test")},
+	}
+
+	for _, test := range tests {
+		if got := getRateLimiter(test.host); got != test.want {
+			t.Errorf("getRateLimiter(%q) = %p, want %p", test.host, got, test.want)
+		}
+	}
+
+	if getRateLimiter("api.example.test") == parent {
+		t.Error("the most specific host should have its own limiter")
+	}
+}
+
+func TestParseRate(t *testing.T) {
+	tests := []struct {
+		value     string
+		rate      float64
+		burst     int
+		wantError bool
+	}{
+		{value: "1/s", rate: 1, burst: 1},
+		{value: "5/s", rate: 5, burst: 5},
+		{value: "2.5/s", rate: 2.5, burst: 3},
+		{value: "5/s:10", rate: 5, burst: 10},
+		{value: "30/m", rate: 0.5, burst: 1},
+		{value: "3600/h", rate: 1, burst: 1},
+		{value: "5", wantError: true},
+		{value: "5/d", wantError: true},
+		{value: "x/s", wantError: true},
+		{value: "-1/s", wantError: true},
+		{value: "5/s:0", wantError: true},
+		{value: "5/s:x", wantError: true},
+	}
+
+	for _, test := range tests {
+		rate, burst, err := parseRate(test.value)
+		if test.wantError {
+			if err == nil {
+				t.Errorf("parseRate(%q) should fail", test.value)
+			}
+			continue
+		}
+
+		if err != nil || rate != test.rate || burst != test.burst {
+			t.Errorf("parseRate(%q) = %v, %v, %v, want %v, %v", test.value, rate, burst, err, test.rate, test.burst)
+		}
+	}
+}
+
+func TestSetRateLimits(t *testing.T) {
+	t.Cleanup(func() {
+		SetRateLimit("one.test", 0, 0)
+		SetRateLimit("two.test", 0, 0)
+	})
+
+	if err := SetRateLimits("one.test=1/s, two.test=5/s:10,"); err != nil {
+		t.Fatal(err)
+	}
+
+	if getRateLimiter("one.test") == nil || getRateLimiter("two.test") == nil {
+		t.Error("limits were not applied")
+	}
+
+	for _, spec := range []string{"one.test", "=1/s", "one.test=1"} {
+		if err := SetRateLimits(spec); err == nil {
+			t.Errorf("SetRateLimits(%q) should fail", spec)
+		}
+	}
+}
+
+func TestDifferentHostsAreNotSerialized(t *testing.T) {
+	setTestRateLimit(t, "first.test", 5, 1)
+	setTestRateLimit(t, "second.test", 5, 1)
+
+	// Use up the burst of both hosts, so that the next request to each has to wait 200ms
+	waitForHost("first.test")
+	waitForHost("second.test")
+
+	start := time.Now()
+	var wg sync.WaitGroup
+	for _, host := range []string{"first.test", "second.test"} {
+		wg.Add(1)
+		go func(host string) {
+			defer wg.Done()
+			waitForHost(host)
+		}(host)
+	}
+	wg.Wait()
+
+	if elapsed := time.Since(start); elapsed > 350*time.Millisecond {
+		t.Fatalf("waiting on two hosts took %v, want about 200ms", elapsed)
+	}
+}
+
+func TestUnlimitedHostDoesNotWait(t *testing.T) {
+	start := time.Now()
+	for i := 0; i < 100; i++ {
+		waitForHost("unlimited.test")
+	}
+
+	if elapsed := time.Since(start); elapsed > 50*time.Millisecond {
+		t.Fatalf("unlimited host waited %v", elapsed)
+	}
+}
+
+func TestRetriesAreRateLimited(t *testing.T) {
+	var mu sync.Mutex
+	var attempts []time.Time
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		attempts = append(attempts, time.Now())
+		if len(attempts) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+
+	serverURL, _ := url.Parse(server.URL)
+	setTestRateLimit(t, serverURL.Hostname(), 5, 1)
+
+	client := retryablehttp.NewClient()
+	client.Logger = nil
+	client.RetryWaitMin = time.Millisecond
+	client.RetryWaitMax = time.Millisecond
+	LimitRequests(client)
+
+	res, err := SendHTTPRequest(&WHTTPReq{Method: "GET", URL: server.URL}, client)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if res.StatusCode != 200 || len(attempts) != 3 {
+		t.Fatalf("got status %d after %d attempts, want 200 after 3", res.StatusCode, len(attempts))
+	}
+
+	// Each retry waits for the limit of 5 requests per second, not just the retry delay
+	for i := 1; i < len(attempts); i++ {
+		if gap := attempts[i].Sub(attempts[i-1]); gap < 150*time.Millisecond {
+			t.Errorf("attempt %d came %v after the previous one, want about 200ms", i+1, gap)
+		}
+	}
+}
@@ -36,6 +36,7 @@ type WHTTPRes struct {
 	HTTPTitle      string
 	BodyString     string
 	Headers        http.Header
+	FinalURL       string // URL of the last request, after redirects
 }
 
 var retryClient *retryablehttp.Client
@@ -49,12 +50,16 @@ func init() {
 
 	// Don't print debug messages
 	retryClient.Logger = log.New(io.Discard, "", 0)
+
+	LimitRequests(retryClient)
 }
 
 func GetDefaultClient() *retryablehttp.Client {
 	return retryClient
 }
 
+// SendHTTPRequest sends wReq with customClient, or the default client if nil.
+// Custom clients only follow rate limits if set up with LimitRequests
 func SendHTTPRequest(wReq *WHTTPReq, customClient *retryablehttp.Client) (wRes *WHTTPRes, err error) {
 	client := customClient
 	if client == nil {
@@ -102,6 +107,7 @@ func SendHTTPRequest(wReq *WHTTPReq, customClient *retryablehttp.Client) (wRes *
 	wRes = &WHTTPRes{
 		StatusCode: resp.StatusCode,
 		Headers:    resp.Header,
+		FinalURL:   resp.Request.URL.String(),
 	}
 
 	bodyBytes, err := ioutil.ReadAll(resp.Body)